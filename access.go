@@ -0,0 +1,90 @@
+//go:build js && wasm
+
+// Accessibility for Video Poker: screen readers, keyboard-only play, and high contrast
+
+// Screen readers get the state of the game from:
+//	- the aria-label and aria-pressed attributes of the cards (see GUI_update_card_label() in main.go)
+//	- the message line, which is an ARIA live region
+//	- the "announce" live region, which is hidden from view, for deals, holds, draws and wins
+//
+// The cards can be given the keyboard focus with the Tab key, or moved between with the
+// left and right arrow keys. The Space or Enter key holds or un-holds the card with the focus.
+
+package main
+
+import (
+	"fmt"
+	"syscall/js"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+// Say something with the screen reader, using the hidden live region
+//	<div id="announce" aria-live="polite">
+
+func GUI_announce(msg string) {
+	later(func() {
+		js.Global().Get("document").Call("getElementById", "announce").Set("textContent", msg)
+	})
+}
+
+// Keys for a card that has the keyboard focus.
+// Returns true if the key was used.
+
+func card_key(event, target js.Value, code string) bool {
+	var n int
+
+	id := target.Get("id").String()
+	if len(id) != 5 || id[:4] != "card" { return false }
+	n = int(id[4] - '1')	// 0 to 4
+	if n < 0 || n >= vp.CARDS { return false }
+
+	switch code {
+		case "Space", "Enter":
+			if !busy() { vp.ToggleHold(n) }
+		case "ArrowLeft":
+			if n > 0 { n-- }
+			js.Global().Get("document").Call("getElementById", fmt.Sprintf("card%d", n+1)).Call("focus")
+		case "ArrowRight":
+			if n < vp.CARDS-1 { n++ }
+			js.Global().Get("document").Call("getElementById", fmt.Sprintf("card%d", n+1)).Call("focus")
+		default:
+			return false
+	}
+
+	event.Call("stopPropagation")
+	event.Call("preventDefault")
+	return true
+}
+
+// High contrast mode. It's done with the "highcontrast" class on <body>,
+// and is saved in localStorage like the key bindings.
+
+const contrast_storage = "videopoker.contrast"
+
+var high_contrast bool
+
+func set_contrast(on bool) {
+	high_contrast = on
+	js.Global().Get("document").Get("body").Get("classList").Call("toggle", "highcontrast", on)
+}
+
+func load_contrast() {
+	set_contrast(js.Global().Get("localStorage").Call("getItem", contrast_storage).String() == "on")
+}
+
+func switch_contrast() {
+	set_contrast(!high_contrast)
+	value := "off"
+	if high_contrast { value = "on" }
+	js.Global().Get("localStorage").Call("setItem", contrast_storage, value)
+	if high_contrast { GUI_announce(vp.Tr("High contrast on")) } else { GUI_announce(vp.Tr("High contrast off")) }
+}
+
+// Callback for the High Contrast button in the Settings panel
+
+func toggle_contrast(this js.Value, args []js.Value) interface{} {
+	js.Global().Get("document").Get("activeElement").Call("blur")
+	switch_contrast()
+	return nil
+}
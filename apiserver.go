@@ -0,0 +1,362 @@
+//go:build !js
+
+// The game, played on the server, with a JSON API
+//
+// In this mode the server has the deck and the chips, so the score can't be changed
+// by the browser. The web page plays this way when it's opened with ?remote
+// (see remote.go), and other programs can use it too.
+//
+//	POST /api/session			start a session: { "variant": "JacksOrBetter", "lang": "en", "name": "Kim" }
+//	GET  /api/session/{id}/state		the state of the game
+//	POST /api/session/{id}/bet		{ "bet": 5 }
+//	POST /api/session/{id}/deal
+//	POST /api/session/{id}/draw		{ "holds": [true, false, true, false, false] }
+//	POST /api/session/{id}/game		change the game: { "variant": "BonusPoker" }
+//	POST /api/session/{id}/quit		end the session
+//	POST /api/session/{id}/new		start a new session after quitting
+//	POST /api/session/{id}/limits		{ "limits": { "lossLimit": 100, "winGoal": 0, "maxMinutes": 60, "checkMinutes": 15 } }
+//	POST /api/session/{id}/acknowledge	go on after play stops at a limit
+//
+// Each answer is like this:
+//
+//	{ "id": "...", "state": { ...see Snapshot in videopoker/api.go... }, "calls": [ ... ], "limits": { ... } }
+//
+// where "calls" are what the engine did to its View (see Script in videopoker/view.go),
+// so the web page can show them the same way as when it plays by itself.
+// When something can't be done, like drawing before dealing, the answer has an
+// "error" as well, with the status 409 (Conflict).
+//
+// The limits (see videopoker/limits.go) are checked by the server's engine, and
+// "limits" has them and the counts so far, as LimitState. A session can be started
+// with "limits" from an earlier one, so a new session doesn't get around them.
+// Sessions in tournaments are started another way (see tournament.go).
+// Any request can have "lang" for the language of the messages.
+// When a session ends, it's put on the leaderboard (see leaderboard.go) with the
+// name it was started with, and the answer to the quit has its "rank" and the "total".
+// Sessions that aren't used for an hour are forgotten.
+// The answer that starts a session has a "watch" id, for spectators (see spectate.go),
+// except in a tournament.
+
+package main
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+)
+
+const max_sessions = 10000
+const session_timeout = time.Hour
+
+type api_session struct {
+	game *vp.Session
+	name string	// for the leaderboard
+	used time.Time
+	entry *entrant	// in a tournament (see tournament.go), or nil
+	watch *watchers	// the spectators (see spectate.go)
+}
+
+var sessions map[string]*api_session = map[string]*api_session{}
+var sessions_lock sync.Mutex
+
+type api_request struct {
+	Variant string	`json:"variant"`
+	Lang string	`json:"lang"`
+	Bet int		`json:"bet"`
+	Holds []bool	`json:"holds"`
+	Name string	`json:"name"`
+	Limits *vp.LimitState	`json:"limits"`
+}
+
+type api_response struct {
+	ID string		`json:"id"`
+	State vp.Snapshot	`json:"state"`
+	Calls []vp.Call		`json:"calls"`
+	Error string		`json:"error,omitempty"`
+	Rank int		`json:"rank,omitempty"`	// on the leaderboard, when the session ends
+	Total int		`json:"total,omitempty"`
+	Watch string		`json:"watch,omitempty"`	// the id for watching the session, when it starts
+	Limits *vp.LimitState	`json:"limits,omitempty"`
+}
+
+// Put a session's state and limits in an answer. It's called in the session's Do().
+
+func (resp *api_response) get_state() {
+	limits := vp.GetLimits()
+	resp.State, resp.Limits = vp.GetState(), &limits
+}
+
+// Start a new session with the limits of an earlier one, if there are any
+
+func restore_limits(s *api_session, script *vp.Script, l *vp.LimitState) {
+	if l != nil { s.game.Do(script, func() { vp.RestoreLimits(*l) }) }
+}
+
+func register_api(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/session", api_new_session)
+	mux.HandleFunc("GET /api/session/{id}/state", api_state)
+	mux.HandleFunc("POST /api/session/{id}/{action}", api_action)
+	mux.HandleFunc("GET /api/leaderboard", api_leaderboard)
+	register_tournaments(mux)
+	register_spectate(mux)
+	go forget_sessions()
+}
+
+// A random id for a session, which is also what lets a player use it
+
+func new_id() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func random_seed() int64 {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return int64(binary.LittleEndian.Uint64(b))
+}
+
+func read_json(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, 4096)
+	err := json.NewDecoder(r.Body).Decode(req)
+	if err != nil && !errors.Is(err, io.EOF) {	// an empty body is fine
+		api_error(w, http.StatusBadRequest, fmt.Sprintf("bad request: %v", err))
+		return false
+	}
+	return true
+}
+
+func read_request(w http.ResponseWriter, r *http.Request) (api_request, bool) {
+	var req api_request
+	ok := read_json(w, r, &req)
+	return req, ok
+}
+
+func api_json(w http.ResponseWriter, status int, resp interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(resp)
+}
+
+func api_reply(w http.ResponseWriter, status int, resp api_response) {
+	api_json(w, status, resp)
+}
+
+// Keep a new session, and give it an id
+
+func add_session(w http.ResponseWriter, s *api_session) (string, bool) {
+	sessions_lock.Lock()
+	defer sessions_lock.Unlock()
+	if len(sessions) >= max_sessions {
+		api_error(w, http.StatusServiceUnavailable, "too many sessions")
+		return "", false
+	}
+	id := new_id()
+	sessions[id] = s
+	s.watch = new_watchers()
+	if s.entry == nil { watched[s.watch.id] = s }	// tournaments can't be watched (see spectate.go)
+	return id, true
+}
+
+func api_error(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{ "error": msg })
+}
+
+func find_session(w http.ResponseWriter, r *http.Request) (string, *api_session) {
+	id := r.PathValue("id")
+	sessions_lock.Lock()
+	s, ok := sessions[id]
+	if ok { s.used = time.Now() }
+	sessions_lock.Unlock()
+	if !ok {
+		api_error(w, http.StatusNotFound, "no such session")
+		return id, nil
+	}
+	return id, s
+}
+
+// POST /api/session
+
+func api_new_session(w http.ResponseWriter, r *http.Request) {
+	req, ok := read_request(w, r)
+	if !ok { return }
+
+	g := vp.JacksOrBetter
+	if req.Variant != "" {
+		var err error
+		if g, err = vp.FindGame(req.Variant); err != nil {
+			api_error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+
+	script := &vp.Script{}
+	s := &api_session{ game: vp.NewSession(script, g, random_seed(), req.Lang), name: player_name(req.Name), used: time.Now() }
+	id, ok := add_session(w, s)
+	if !ok { return }
+	restore_limits(s, script, req.Limits)
+
+	resp := api_response{ ID: id, Calls: script.Calls, Watch: s.watch.id }
+	s.game.Do(script, resp.get_state)
+	count("game_sessions_started_total", "", 1)
+	api_reply(w, http.StatusOK, resp)
+}
+
+// GET /api/session/{id}/state
+
+func api_state(w http.ResponseWriter, r *http.Request) {
+	id, s := find_session(w, r)
+	if s == nil { return }
+
+	resp := api_response{ ID: id, Calls: []vp.Call{} }
+	s.game.Do(vp.NoView{}, resp.get_state)
+	api_reply(w, http.StatusOK, resp)
+}
+
+// POST /api/session/{id}/bet, deal, draw, game, quit, new, limits and acknowledge.
+// They are done with the text commands (see videopoker/command.go), which check
+// that they can be done.
+
+func api_action(w http.ResponseWriter, r *http.Request) {
+	var commands []string
+
+	id, s := find_session(w, r)
+	if s == nil { return }
+	req, ok := read_request(w, r)
+	if !ok { return }
+
+	// the holds are put in first, in the same Do as the draw, so another request
+	// can't come in between. They aren't shown, since the player has already seen them.
+	var holds string
+
+	switch r.PathValue("action") {
+		case "bet":
+			commands = []string{ fmt.Sprintf("bet %d", req.Bet) }
+		case "deal":
+			commands = []string{ "deal" }
+		case "draw":
+			if len(req.Holds) != vp.CARDS {
+				api_error(w, http.StatusBadRequest, fmt.Sprintf("holds must have %d cards", vp.CARDS))
+				return
+			}
+			holds = "hold"
+			for i, h := range req.Holds {
+				if h { holds += fmt.Sprintf(" %d", i+1) }
+			}
+			commands = []string{ "draw" }
+		case "game":
+			commands = []string{ "game " + req.Variant }
+		case "quit":
+			commands = []string{ "quit" }
+		case "new":
+			commands = []string{ "new" }
+		case "limits":
+			if req.Limits == nil {
+				api_error(w, http.StatusBadRequest, "no limits")
+				return
+			}
+			l := req.Limits
+			commands = []string{ fmt.Sprintf("limits %d %d %d %d", l.LossLimit, l.WinGoal, l.MaxMinutes, l.CheckMinutes) }
+		case "acknowledge":
+			commands = []string{ "ok" }
+		default:
+			api_error(w, http.StatusNotFound, "unknown action")
+			return
+	}
+	if s.entry != nil {
+		if msg := s.entry.refuse(r.PathValue("action")); msg != "" {
+			api_error(w, http.StatusConflict, msg)
+			return
+		}
+	}
+
+	status := http.StatusOK
+	ended := false
+	var record score_record
+	script := &vp.Script{}
+	resp := api_response{ ID: id }
+	s.game.Do(script, func() {
+		if holds != "" {
+			vp.Command(holds)
+			script.Calls = nil
+		}
+		if req.Lang != "" { vp.SetLanguage(req.Lang) }
+		before, hands := vp.State(), vp.HandsPlayed()
+		for _, c := range commands {
+			if err := vp.Command(c); err != nil {
+				status = http.StatusConflict
+				resp.Error = err.Error()
+				break
+			}
+		}
+		if s.entry != nil { s.entry.check_hands() }
+		resp.get_state()
+		if n := vp.HandsPlayed() - hands; n > 0 { count("game_hands_total", label("variant", resp.State.Variant), float64(n)) }
+		if before != vp.Over && vp.State() == vp.Over {
+			count("game_sessions_ended_total", label("variant", resp.State.Variant), 1)
+		}
+		if before != vp.Over && vp.State() == vp.Over && vp.HandsPlayed() > 0 && s.entry == nil {
+			ended = true
+			record = score_record{ Name: s.name, Variant: resp.State.Variant, Hands: resp.State.HandsPlayed,
+				Final: resp.State.Score, Peak: resp.State.PeakScore, Time: time.Now().UTC() }
+		}
+	})
+	if s.entry != nil { s.entry.update(resp.State) }
+	if ended && scores != nil { resp.Rank, resp.Total = rank_session(s, record, script) }
+	resp.Calls = script.Calls
+	if resp.Calls == nil { resp.Calls = []vp.Call{} }
+	api_reply(w, status, resp)
+	if status == http.StatusOK { s.watch.update(resp, req.Holds) }
+}
+
+// Put a session that has ended on the leaderboard,
+// and add where it ranked to the summary the player sees
+
+func rank_session(s *api_session, record score_record, script *vp.Script) (int, int) {
+	rank, total, err := scores.add(record)
+	if err != nil {
+		slog.Error("can't add to the leaderboard", "error", err)
+		return 0, 0
+	}
+
+	var line string
+	s.game.Do(vp.NoView{}, func() { line = fmt.Sprintf(vp.Tr("Rank: %s of %s"), vp.Number(rank), vp.Number(total)) })
+	for i := len(script.Calls) - 1; i >= 0; i-- {
+		if c := &script.Calls[i]; c.Method == "Summary" && len(c.Lines) > 0 {
+			c.Lines = append(c.Lines, line)
+			break
+		}
+	}
+	return rank, total
+}
+
+// Forget the sessions that haven't been used for a while,
+// except the ones in tournaments that are still going
+
+func forget_sessions() {
+	for range time.Tick(time.Minute) {
+		sessions_lock.Lock()
+		for id, s := range sessions {
+			if time.Since(s.used) > session_timeout && (s.entry == nil || s.entry.t.is_closed()) {
+				delete(sessions, id)
+				delete(watched, s.watch.id)
+				go s.watch.close_all()
+			}
+		}
+		sessions_lock.Unlock()
+		forget_tournaments()
+	}
+}
@@ -0,0 +1,111 @@
+//go:build !js
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+)
+
+func api_post(t *testing.T, server *httptest.Server, path, body string) (int, api_response) {
+	t.Helper()
+	var resp api_response
+
+	r, err := http.Post(server.URL + path, "application/json", strings.NewReader(body))
+	if err != nil { t.Fatal(err) }
+	defer r.Body.Close()
+	json.NewDecoder(r.Body).Decode(&resp)
+	return r.StatusCode, resp
+}
+
+func TestAPI(t *testing.T) {
+	vp.Console = io.Discard
+	mux := http.NewServeMux()
+	register_api(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	status, resp := api_post(t, server, "/api/session", `{"variant":"BonusPoker"}`)
+	if status != http.StatusOK || resp.ID == "" || resp.State.Variant != "BonusPoker" {
+		t.Fatalf("new session: %d %+v", status, resp)
+	}
+	id := "/api/session/" + resp.ID
+
+	if status, resp = api_post(t, server, id + "/draw", `{"holds":[false,false,false,false,false]}`); status != http.StatusConflict {
+		t.Errorf("draw before dealing: %d %+v", status, resp)
+	}
+	if status, resp = api_post(t, server, id + "/bet", `{"bet":2}`); status != http.StatusOK || resp.State.Bet != 20 {
+		t.Errorf("bet: %d %+v", status, resp)
+	}
+	if status, resp = api_post(t, server, id + "/deal", ``); status != http.StatusOK || resp.State.Phase != "draw" || len(resp.Calls) == 0 {
+		t.Fatalf("deal: %d %+v", status, resp)
+	}
+	dealt := resp.State.Hand
+
+	if status, resp = api_post(t, server, id + "/draw", `{"holds":[true,false,false,false,true]}`); status != http.StatusOK {
+		t.Fatalf("draw: %d %+v", status, resp)
+	}
+	if resp.State.Phase != "deal" || resp.State.HandsPlayed != 1 || resp.State.Hand[0] != dealt[0] || resp.State.Hand[4] != dealt[4] {
+		t.Errorf("after the draw: %+v (dealt %v)", resp.State, dealt)
+	}
+
+	/* the server keeps the state */
+	r, err := http.Get(server.URL + id + "/state")
+	if err != nil { t.Fatal(err) }
+	var state api_response
+	json.NewDecoder(r.Body).Decode(&state)
+	r.Body.Close()
+	if state.State.Score != resp.State.Score || state.State.Hand[1] != resp.State.Hand[1] {
+		t.Errorf("state: %+v, want %+v", state.State, resp.State)
+	}
+
+	if status, _ = api_post(t, server, "/api/session/nosuchsession/deal", ``); status != http.StatusNotFound {
+		t.Errorf("unknown session: %d", status)
+	}
+	if status, _ = api_post(t, server, id + "/draw", `{"holds":[true]}`); status != http.StatusBadRequest {
+		t.Errorf("draw with one hold: %d", status)
+	}
+	if status, resp = api_post(t, server, id + "/quit", ``); status != http.StatusOK || resp.State.Phase != "over" {
+		t.Errorf("quit: %d %+v", status, resp)
+	}
+}
+
+// A session started with limits that have stopped play, as after reloading the page
+
+func TestAPILimits(t *testing.T) {
+	vp.Console = io.Discard
+	mux := http.NewServeMux()
+	register_api(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	status, resp := api_post(t, server, "/api/session",
+		`{"limits":{"lossLimit":100,"started":"2026-10-19T20:00:00Z","net":-100,"stopped":"loss"}}`)
+	if status != http.StatusOK || resp.Limits == nil || resp.Limits.Stopped != "loss" || len(resp.Calls) == 0 {
+		t.Fatalf("new session: %d %+v", status, resp)
+	}
+	id := "/api/session/" + resp.ID
+
+	if status, resp = api_post(t, server, id + "/deal", ``); status != http.StatusConflict || resp.State.Phase != "deal" {
+		t.Errorf("deal while stopped: %d %+v", status, resp)
+	}
+	if status, resp = api_post(t, server, id + "/limits", `{"limits":{}}`); status != http.StatusConflict || resp.Limits.LossLimit != 100 {
+		t.Errorf("removing the limits while stopped: %d %+v", status, resp)
+	}
+	if status, resp = api_post(t, server, id + "/acknowledge", ``); status != http.StatusOK || resp.State.Phase != "over" ||
+		resp.Limits.Stopped != "" || resp.Limits.Net != 0 {
+		t.Errorf("acknowledge: %d %+v", status, resp)
+	}
+	if status, resp = api_post(t, server, id + "/limits", `{"limits":{}}`); status != http.StatusOK || resp.Limits.LossLimit != 0 {
+		t.Errorf("removing the limits: %d %+v", status, resp)
+	}
+	if status, _ = api_post(t, server, id + "/limits", ``); status != http.StatusBadRequest {
+		t.Errorf("no limits: %d", status)
+	}
+}
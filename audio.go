@@ -0,0 +1,201 @@
+//go:build js && wasm
+
+// Sound effects for Video Poker, using the Web Audio API
+
+// The sounds are synthesized as they are played, from short tones made by
+// oscillators, so there are no audio files to download.
+// The volume and whether the sound is muted are chosen in the Settings panel
+// (or muted with a key; see keys.go), and saved in localStorage.
+
+package main
+
+import (
+	"strconv"
+	"syscall/js"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+// A note in a sound effect
+
+type note struct {
+	freq float64	// frequency in Hz
+	start float64	// when it starts, in seconds from the beginning of the sound
+	length float64	// in seconds
+	wave string	// oscillator type: "sine", "square", "triangle" or "sawtooth"
+}
+
+// The sound effects, by name
+
+var sounds map[string][]note = map[string][]note {
+	"deal":   { {220, 0, 0.05, "triangle"}, {330, 0.05, 0.05, "triangle"} },
+	"draw":   { {330, 0, 0.05, "triangle"}, {220, 0.05, 0.05, "triangle"} },
+	"card":   { {1200, 0, 0.02, "square"} },
+	"hold":   { {880, 0, 0.06, "sine"} },
+	"unhold": { {440, 0, 0.06, "sine"} },
+	"tick":   { {1500, 0, 0.015, "square"} },
+
+	// wins, from small to big
+	"win1": { {523, 0, 0.1, "sine"}, {659, 0.1, 0.15, "sine"} },
+	"win2": { {523, 0, 0.1, "sine"}, {659, 0.1, 0.1, "sine"}, {784, 0.2, 0.2, "sine"} },
+	"win3": { {523, 0, 0.1, "square"}, {659, 0.1, 0.1, "square"}, {784, 0.2, 0.1, "square"},
+		{1047, 0.3, 0.3, "square"} },
+	"win4": { {523, 0, 0.1, "square"}, {659, 0.1, 0.1, "square"}, {784, 0.2, 0.1, "square"},
+		{1047, 0.3, 0.15, "square"}, {784, 0.45, 0.1, "square"}, {1047, 0.55, 0.5, "square"} },
+}
+
+// The win sound for each kind of hand
+
+var win_sounds [vp.NUMHANDTYPES]string = [vp.NUMHANDTYPES]string {
+	"win4",	/* royal flush */
+	"win4",	/* straight flush */
+	"win4",	/* 4 aces with a 2, 3 or 4 */
+	"win4",	/* 4 2s, 3s or 4s with an ace, 2, 3 or 4 */
+	"win3",	/* 4 aces */
+	"win3",	/* 4 2s, 3s or 4s */
+	"win3",	/* 4 of a kind */
+	"win3",	/* full house */
+	"win2",	/* flush */
+	"win2",	/* straight */
+	"win2",	/* 3 of a kind */
+	"win1",	/* two pair */
+	"win1",	/* pair */
+	"",	/* nothing */
+}
+
+const default_volume = 50
+const volume_storage = "videopoker.volume"
+const mute_storage = "videopoker.mute"
+
+var volume int = default_volume	// 0 to 100
+var muted bool
+
+// The AudioContext, and the gain node all of the sounds go through, for the volume.
+// Browsers only allow sound after the player has done something on the page,
+// so these are created when the first sound is played.
+
+var audio js.Value
+var master js.Value
+
+func start_audio() bool {
+	if audio.Truthy() { return true }
+
+	ac := js.Global().Get("AudioContext")
+	if !ac.Truthy() { ac = js.Global().Get("webkitAudioContext") }
+	if !ac.Truthy() { return false }	// no Web Audio in this browser
+
+	audio = ac.New()
+	master = audio.Call("createGain")
+	master.Call("connect", audio.Get("destination"))
+	set_gain()
+	return true
+}
+
+func set_gain() {
+	if !master.Truthy() { return }
+	gain := float64(volume) / 100
+	if muted { gain = 0 }
+	master.Get("gain").Set("value", gain)
+}
+
+// Play a sound right now
+
+func play(name string) {
+	if muted || volume == 0 { return }
+	notes, ok := sounds[name]
+	if !ok || !start_audio() { return }
+
+	now := audio.Get("currentTime").Float()
+	for _, n := range notes {
+		t := now + n.start
+
+		osc := audio.Call("createOscillator")
+		osc.Set("type", n.wave)
+		osc.Get("frequency").Set("value", n.freq)
+
+		// fade each note out, so it doesn't click at the end
+		env := audio.Call("createGain")
+		env.Get("gain").Call("setValueAtTime", 0.3, t)
+		env.Get("gain").Call("exponentialRampToValueAtTime", 0.001, t + n.length)
+
+		osc.Call("connect", env)
+		env.Call("connect", master)
+		osc.Call("start", t)
+		osc.Call("stop", t + n.length)
+	}
+}
+
+// Play a sound, after the animation if one is running.
+// This is how the engine plays sounds.
+
+func GUI_sound(name string) {
+	later(func() { play(name) })
+}
+
+// Play the sound for a winning hand
+
+func GUI_win_sound(handtype int) {
+	if win_sounds[handtype] == "" { return }
+	GUI_sound(win_sounds[handtype])
+}
+
+func set_volume(v int) {
+	if v < 0 { v = 0 }
+	if v > 100 { v = 100 }
+	volume = v
+	set_gain()
+	js.Global().Get("localStorage").Call("setItem", volume_storage, strconv.Itoa(v))
+	js.Global().Get("document").Call("getElementById", "volume").Set("value", v)
+}
+
+func set_mute(on bool) {
+	muted = on
+	set_gain()
+	value := "off"
+	if on { value = "on" }
+	js.Global().Get("localStorage").Call("setItem", mute_storage, value)
+	label := "Sound: on"
+	if on { label = "Sound: off" }
+	js.Global().Get("document").Call("getElementById", "mutebutton").Set("textContent", vp.Tr(label))
+}
+
+func switch_mute() {
+	set_mute(!muted)
+	if muted { GUI_announce(vp.Tr("Sound off")) } else { GUI_announce(vp.Tr("Sound on")) }
+}
+
+func load_audio_settings() {
+	v := default_volume
+	saved := js.Global().Get("localStorage").Call("getItem", volume_storage)
+	if !saved.IsNull() {
+		if n, err := strconv.Atoi(saved.String()); err == nil { v = n }
+	}
+	set_volume(v)
+	set_mute(js.Global().Get("localStorage").Call("getItem", mute_storage).String() == "on")
+}
+
+// Callbacks for the Settings panel
+
+// The volume slider: volume(this.value)
+
+func choose_volume(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 { return nil }
+	if n, err := strconv.Atoi(args[0].String()); err == nil {
+		set_volume(n)
+		play("hold")	// so the player can hear how loud it is
+	}
+	return nil
+}
+
+// The Sound on/off button
+
+func toggle_mute(this js.Value, args []js.Value) interface{} {
+	js.Global().Get("document").Get("activeElement").Call("blur")
+	switch_mute()
+	return nil
+}
+
+func register_audio_callbacks() {
+	js.Global().Set("volume", js.FuncOf(choose_volume))
+	js.Global().Set("mute", js.FuncOf(toggle_mute))
+}
@@ -0,0 +1,139 @@
+//go:build js && wasm
+
+// Autoplay: the page plays the game by itself, in the Autoplay panel
+//
+// The player picks a strategy (the best holds, or the simple rules of vp.SimpleHold()),
+// how fast to play, and when to stop: after a number of hands, a royal flush, a win of
+// at least some chips, or when the chips go below or above an amount. Then a goroutine
+// calls vp.Autoplay.Step() until it says to stop, waiting for each animation to end,
+// so the page shows every deal, hold and draw as when the player plays.
+// Pressing any key stops it too, and input is ignored while it runs (see busy() in main.go).
+//
+// Finding the best hold takes a while, and WebAssembly has only one thread, so
+// the goroutine sleeps for a moment every so often while it does (see Autoplay.Pause),
+// to let the page handle keys and clicks. A key that stops autoplay then asks the
+// goroutine to stop, and input is still ignored until it has finished the step.
+//
+// Autoplay is only for the game played in the page. In remote mode and for spectators
+// (see remote.go and live.go), the game is played on the server, so the panel is hidden.
+
+package main
+
+import (
+	"strconv"
+	"syscall/js"
+	"time"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+var autoplaying bool		// true while autoplay is running
+var autoplay_stop string	// why autoplay was asked to stop, or ""
+
+// The pause after each deal and draw, for the speeds in the panel
+
+var autoplay_speeds map[string]time.Duration = map[string]time.Duration {
+	"fast":   100 * time.Millisecond,
+	"normal": 700 * time.Millisecond,
+	"slow":   1500 * time.Millisecond,
+}
+
+// The number in an input in the panel, or 0 if it's empty
+
+func autoplay_input(id string) int {
+	input := js.Global().Get("document").Call("getElementById", id)
+	n, err := strconv.Atoi(input.Get("value").String())
+	if err != nil || n < 0 {
+		input.Set("value", "")
+		return 0
+	}
+	return n
+}
+
+// Show the Start or Stop button
+
+func GUI_update_autoplay() {
+	label := "Start"
+	if autoplaying { label = "Stop" }
+	button := js.Global().Get("document").Call("getElementById", "autoplaystart")
+	button.Call("setAttribute", "data-msg", label)
+	button.Set("textContent", vp.Tr(label))
+}
+
+func start_autoplay() {
+	document := js.Global().Get("document")
+	a := &vp.Autoplay{
+		Simple: document.Call("getElementById", "autoplaystrategy").Get("value").String() == "simple",
+		Hands: autoplay_input("autoplayhands"),
+		Royal: document.Call("getElementById", "autoplayroyal").Get("checked").Bool(),
+		WinAtLeast: autoplay_input("autoplaywin"),
+		Below: autoplay_input("autoplaybelow"),
+		Above: autoplay_input("autoplayabove"),
+	}
+	a.Pause = func() { time.Sleep(time.Millisecond) }
+	delay, ok := autoplay_speeds[document.Call("getElementById", "autoplayspeed").Get("value").String()]
+	if !ok { delay = autoplay_speeds["normal"] }
+
+	autoplaying, autoplay_stop = true, ""
+	GUI_update_autoplay()
+	go func() {
+		msg := ""
+		for msg == "" {
+			// wait for the cards to be turned over and the score to count up
+			for drawing() { time.Sleep(20 * time.Millisecond) }
+			if msg = autoplay_stop; msg != "" { break }
+			if msg = a.Step(); msg != "" { break }
+			time.Sleep(delay)
+			msg = autoplay_stop
+		}
+		autoplaying = false
+		GUI_update_autoplay()
+		later(func() {
+			GUI_update_message(msg)
+			GUI_announce(msg)
+		})
+	}()
+}
+
+// Ask autoplay to stop, after the step it's doing
+
+func stop_autoplay(msg string) {
+	if autoplaying && autoplay_stop == "" { autoplay_stop = msg }
+}
+
+// Callback for the Autoplay button, which shows or hides the panel
+
+func toggle_autoplay_panel(this js.Value, args []js.Value) interface{} {
+	js.Global().Get("document").Get("activeElement").Call("blur")
+	panel := js.Global().Get("document").Call("getElementById", "autoplay")
+	if panel.Get("style").Get("display").String() == "block" {
+		panel.Get("style").Set("display", "none")
+	} else {
+		panel.Get("style").Set("display", "block")
+	}
+	return nil
+}
+
+// Callback for the Start/Stop button in the panel
+
+func autoplay_button(this js.Value, args []js.Value) interface{} {
+	js.Global().Get("document").Get("activeElement").Call("blur")
+	if autoplaying {
+		stop_autoplay(vp.Tr("Autoplay stopped"))
+		return nil
+	}
+	if busy() || vp.LimitStopped() { return nil }
+	start_autoplay()
+	return nil
+}
+
+// In remote mode and for spectators, there's no autoplay
+
+func autoplay_hide() {
+	js.Global().Get("document").Call("getElementById", "autoplaybutton").Set("style", "display: none;")
+}
+
+func register_autoplay_callbacks() {
+	js.Global().Set("autoplaypanel", js.FuncOf(toggle_autoplay_panel))
+	js.Global().Set("autoplay", js.FuncOf(autoplay_button))
+}
@@ -0,0 +1,160 @@
+// Video Poker in a terminal
+//
+// This runs the same game engine as the web app (see videopoker/ at the top of the
+// repository), drawn with ANSI escape sequences, so the game can be played in a
+// terminal window, or over SSH. The keys are the same as the web app's "home" key bindings:
+//
+//	Space j k l ;	hold or un-hold cards 1 to 5
+//	Enter		deal or draw
+//	1 - 5		bet 10 to 50
+//	Shift+A - I	change the game
+//	q or e		quit
+//
+// With -plain, there are no escape sequences and the keys are typed as lines of text,
+// followed by Enter. An empty line is the Enter key. This is for terminals that
+// can't do more, and for playing with a script.
+//
+// build: go build -o videopoker-tui ./cmd/videopoker-tui
+
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+var plain = flag.Bool("plain", false, "plain text, without escape sequences or raw keys")
+var language = flag.String("lang", "", "language: en, es or de (default: from $LANG)")
+
+const (
+	key_ctrlC = 3
+	key_ctrlD = 4
+	key_ESC = 27
+	key_Return = '\r'
+
+	alt_screen_on = "\x1b[?1049h"
+	alt_screen_off = "\x1b[?1049l"
+	hide_cursor = "\x1b[?25l"
+	show_cursor = "\x1b[?25h"
+)
+
+// Put the terminal in raw mode, so keys are read one at a time, without being echoed.
+// This is done with stty(1), so it works on any Unix-like system.
+// Returns the terminal settings to put back at the end.
+
+func stty(args ...string) (string, error) {
+	cmd := exec.Command("stty", args...)
+	cmd.Stdin = os.Stdin
+	out, err := cmd.Output()
+	return strings.TrimSpace(string(out)), err
+}
+
+func raw_mode() (string, error) {
+	saved, err := stty("-g")
+	if err != nil { return "", err }
+	_, err = stty("raw", "-echo")
+	return saved, err
+}
+
+// The language from the environment, like LANG=de_DE.UTF-8
+
+func env_language() string {
+	for _, v := range []string{ "LC_ALL", "LC_MESSAGES", "LANG" } {
+		l := os.Getenv(v)
+		if l == "" { continue }
+		l = strings.SplitN(l, ".", 2)[0]
+		return strings.ReplaceAll(l, "_", "-")
+	}
+	return "en"
+}
+
+// The keys to show at the bottom of the screen
+
+func help() string {
+	if vp.State() == vp.Over { return vp.Tr("Enter new session   Q exit") }
+	return vp.Tr("Space J K L ; hold   Enter deal/draw   1-5 bet   Shift+A-I game   Q quit")
+}
+
+// Do what a key does. Returns false when the program should end.
+
+func key(k byte) bool {
+	switch {
+		case k == key_ctrlC || k == key_ctrlD:
+			return false
+		case vp.State() == vp.Over && (k == 'q' || k == 'e'):
+			// quitting again, after the summary, leaves the program
+			return false
+	}
+	vp.Key(k)
+	return true
+}
+
+func play_raw(t *vp.Terminal) {
+	buf := make([]byte, 16)
+	for {
+		t.Help = help()
+		t.Draw()
+
+		n, err := os.Stdin.Read(buf)
+		if err != nil || n == 0 { return }
+
+		// arrow keys, function keys, etc. send escape sequences, which aren't used
+		if buf[0] == key_ESC { continue }
+		for _, k := range buf[:n] {
+			if !key(k) { return }
+		}
+	}
+}
+
+func play_plain(t *vp.Terminal) {
+	in := bufio.NewScanner(os.Stdin)
+	for {
+		t.Help = help()
+		t.Draw()
+
+		if !in.Scan() { return }
+		line := in.Text()
+		if line == "" { line = string(rune(key_Return)) }
+		for _, k := range []byte(line) {
+			if !key(k) { return }
+		}
+	}
+}
+
+func main() {
+	flag.Parse()
+
+	if *language == "" { *language = env_language() }
+	vp.SetLanguage(*language)
+
+	// The engine's text mode output would write over the screen
+	vp.Console = io.Discard
+
+	t := vp.NewTerminal(os.Stdout, !*plain)
+
+	if *plain {
+		vp.Start(t)
+		play_plain(t)
+		return
+	}
+
+	saved, err := raw_mode()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "videopoker-tui: can't read keys from the terminal (%v). Try -plain\n", err)
+		os.Exit(1)
+	}
+	fmt.Print(alt_screen_on + hide_cursor)
+
+	vp.Start(t)
+	play_raw(t)
+
+	fmt.Print(show_cursor + alt_screen_off)
+	stty(saved)
+}
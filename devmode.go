@@ -0,0 +1,224 @@
+//go:build !js
+
+// Development mode: ./webserver -dev
+//
+// The web server serves the files in the current directory (or -dir), and watches
+// the Go files. When one is saved, it builds main.wasm again, and tells the page
+// with Server-Sent Events (GET /dev/events), so the page reloads itself with the
+// new main.wasm. If the build fails, the page shows the compiler's errors on top
+// of the game instead. Changes to index.html, the CSS and the JavaScript reload the
+// page without building anything. Changes to the web server itself still need it
+// to be built and started again.
+
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const dev_poll = 500 * time.Millisecond
+const dev_ping = 30 * time.Second
+
+// An event for the page: "version" with the build's number, when there's
+// a new main.wasm, or "failed" with the compiler's errors
+
+type dev_event struct {
+	name string
+	data string
+}
+
+type dev_server struct {
+	dir string
+	lock sync.Mutex
+	pages map[chan dev_event]bool	// the pages that are listening
+	build int			// the number of the last build that worked
+	failed string			// the errors from the last build, if it didn't work
+	done chan bool			// closed when the server is stopping
+}
+
+// The script put in the page
+
+const dev_script = `<script>
+// -dev: reload when main.wasm is built again, and show the errors when it can't be (see devmode.go)
+(function() {
+	var overlay = document.createElement("pre");
+	overlay.style.cssText = "display: none; position: fixed; left: 0; right: 0; top: 0; max-height: 70%; overflow: auto; margin: 0; padding: 1em;" +
+		" z-index: 1000; background: rgba(60,0,0,0.92); color: #fdd; font-size: 14px; white-space: pre-wrap; text-align: left;";
+	document.body.appendChild(overlay);
+
+	var version = null;
+	var events = new EventSource("/dev/events");
+	events.addEventListener("version", function(e) {
+		if (version !== null && e.data !== version) { location.reload(); }
+		version = e.data;
+		overlay.style.display = "none";
+	});
+	events.addEventListener("failed", function(e) {
+		overlay.textContent = "main.wasm didn't build:\n\n" + e.data;
+		overlay.style.display = "block";
+	});
+})();
+</script>
+`
+
+// Start development mode: build main.wasm, and watch for changes
+
+func start_dev(dir string, sf *static_files, mux *http.ServeMux, server *http.Server) {
+	ds := &dev_server{ dir: dir, pages: map[chan dev_event]bool{}, done: make(chan bool) }
+	// a new number each time the server starts, so pages that were open reload too
+	ds.build = int(time.Now().Unix())
+	sf.inject = dev_script
+
+	mux.HandleFunc("GET /dev/events", ds.events)
+	server.RegisterOnShutdown(func() { close(ds.done) })
+
+	ds.rebuild()
+	go ds.watch()
+}
+
+// The files that are watched, and when they were changed
+
+func (ds *dev_server) scan() map[string]time.Time {
+	files := map[string]time.Time{}
+	filepath.WalkDir(ds.dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil { return nil }
+		if d.IsDir() {
+			if path != ds.dir && strings.HasPrefix(d.Name(), ".") { return filepath.SkipDir }
+			return nil
+		}
+		switch filepath.Ext(path) {
+			case ".go", ".html", ".css", ".js":
+				if info, err := d.Info(); err == nil { files[path] = info.ModTime() }
+		}
+		return nil
+	})
+	return files
+}
+
+func (ds *dev_server) watch() {
+	files := ds.scan()
+	for {
+		select {
+			case <-ds.done: return
+			case <-time.After(dev_poll):
+		}
+		now := ds.scan()
+		go_changed, changed := false, false
+		for path, t := range now {
+			if old, ok := files[path]; !ok || !old.Equal(t) {
+				changed = true
+				if strings.HasSuffix(path, ".go") { go_changed = true }
+			}
+		}
+		for path := range files {
+			if _, ok := now[path]; !ok {
+				changed = true
+				if strings.HasSuffix(path, ".go") { go_changed = true }
+			}
+		}
+		files = now
+
+		switch {
+			case go_changed: ds.rebuild()
+			case changed:
+				ds.lock.Lock()
+				ds.build++
+				event := dev_event{ "version", strconv.Itoa(ds.build) }
+				ds.lock.Unlock()
+				ds.send(event)
+		}
+	}
+}
+
+// Build main.wasm, and tell the pages how it went. It's built into another file
+// and then renamed, so a page never gets half of it.
+
+func (ds *dev_server) rebuild() {
+	slog.Info("building main.wasm")
+	start := time.Now()
+	wasm := filepath.Join(ds.dir, "main.wasm")
+	building := wasm + ".building"
+
+	cmd := exec.Command("go", "build", "-o", building, ".")
+	cmd.Dir = ds.dir
+	cmd.Env = append(os.Environ(), "GOOS=js", "GOARCH=wasm")
+	out, err := cmd.CombinedOutput()
+	if err == nil { err = os.Rename(building, wasm) }
+
+	ds.lock.Lock()
+	if err != nil {
+		os.Remove(building)
+		ds.failed = strings.TrimSpace(string(out))
+		if ds.failed == "" { ds.failed = err.Error() }
+	} else {
+		ds.failed = ""
+		ds.build++
+	}
+	event := dev_event{ "version", strconv.Itoa(ds.build) }
+	if ds.failed != "" { event = dev_event{ "failed", ds.failed } }
+	ds.lock.Unlock()
+
+	if event.name == "failed" {
+		slog.Warn("main.wasm didn't build", "errors", event.data)
+	} else {
+		slog.Info("built main.wasm", "duration", time.Since(start))
+	}
+	ds.send(event)
+}
+
+func (ds *dev_server) send(e dev_event) {
+	ds.lock.Lock()
+	defer ds.lock.Unlock()
+	for page := range ds.pages {
+		select {
+			case page <- e:
+			default:	// it isn't keeping up, and will get the next one
+		}
+	}
+}
+
+// GET /dev/events
+
+func (ds *dev_server) events(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-store")
+
+	page := make(chan dev_event, 4)
+	ds.lock.Lock()
+	ds.pages[page] = true
+	page <- dev_event{ "version", strconv.Itoa(ds.build) }
+	if ds.failed != "" { page <- dev_event{ "failed", ds.failed } }
+	ds.lock.Unlock()
+	defer func() {
+		ds.lock.Lock()
+		delete(ds.pages, page)
+		ds.lock.Unlock()
+	}()
+
+	for {
+		select {
+			case e := <-page:
+				fmt.Fprintf(w, "event: %s\n", e.name)
+				for _, line := range strings.Split(e.data, "\n") { fmt.Fprintf(w, "data: %s\n", line) }
+				fmt.Fprintf(w, "\n")
+			case <-time.After(dev_ping):
+				fmt.Fprintf(w, ": ping\n\n")	// so proxies don't close it
+			case <-r.Context().Done():
+				return
+			case <-ds.done:
+				return
+		}
+		if err := rc.Flush(); err != nil { return }
+	}
+}
@@ -0,0 +1,54 @@
+//go:build !js
+
+package main
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestDevEvents(t *testing.T) {
+	ds := &dev_server{ pages: map[chan dev_event]bool{}, done: make(chan bool), build: 7, failed: "x.go:1: oops" }
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /dev/events", ds.events)
+	server := httptest.NewServer(observe(mux))
+	defer server.Close()
+	defer close(ds.done)
+
+	r, err := http.Get(server.URL + "/dev/events")
+	if err != nil { t.Fatal(err) }
+	defer r.Body.Close()
+	if r.Header.Get("Content-Type") != "text/event-stream" { t.Errorf("Content-Type: %q", r.Header.Get("Content-Type")) }
+
+	in := bufio.NewReader(r.Body)
+	read_event := func() string {
+		var lines []string
+		for {
+			line, err := in.ReadString('\n')
+			if err != nil { t.Fatal(err) }
+			if line == "\n" { return strings.Join(lines, "|") }
+			lines = append(lines, strings.TrimSuffix(line, "\n"))
+		}
+	}
+
+	/* what the page is told when it connects, and then the next build */
+	if e := read_event(); e != "event: version|data: 7" { t.Errorf("first event: %q", e) }
+	if e := read_event(); e != "event: failed|data: x.go:1: oops" { t.Errorf("second event: %q", e) }
+	ds.send(dev_event{ "failed", "one\ntwo" })
+	if e := read_event(); e != "event: failed|data: one|data: two" { t.Errorf("two lines: %q", e) }
+	ds.send(dev_event{ "version", "8" })
+	if e := read_event(); e != "event: version|data: 8" { t.Errorf("new version: %q", e) }
+}
+
+func TestDevScript(t *testing.T) {
+	sf := new_static_files(fstest.MapFS{ "index.html": { Data: []byte("<body>game</body>") } })
+	sf.inject = dev_script
+	_, page := static_get(t, sf, "/")
+	if !strings.HasPrefix(page, "<body>game<script>") || !strings.HasSuffix(page, "</script>\n</body>") {
+		t.Errorf("page: %s", page)
+	}
+}
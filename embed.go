@@ -0,0 +1,232 @@
+//go:build js && wasm
+
+// The JavaScript API, for embedding the game in another page
+
+// The game can be controlled and watched from JavaScript, with the vp object:
+//
+//	vp.getState()			the state of the game, as JSON text (see Snapshot in videopoker/api.go)
+//	vp.deal()  vp.draw()		the Deal/Draw button
+//	vp.hold(1, 3, 5)		hold those cards, and no others
+//	vp.bet(5)			bet 5 times the minimum bet
+//	vp.game("JacksOrBetter95")	change the game, by id, number, or part of its name
+//	vp.quit()  vp.newSession()	end the session, and start a new one
+//	vp.on("won", f)  vp.off("won", f)
+//
+// The commands return null when they work, or a message saying why they didn't.
+// They don't work while cards are being dealt, so wait for the "dealt" or "drawn" event
+// before sending the next one.
+//
+// Events are:
+//	dealt	a hand has been dealt
+//	drawn	the cards have been drawn, and the hand was scored
+//	won	after "drawn", if the hand won
+//
+// The listener gets an object like this, after the cards are shown:
+//	{ event: "won", hand: "Full House", handType: 7, win: 90, state: { ...same as getState()... } }
+//
+// A page that has the game in an <iframe> can do the same with postMessage:
+//
+//	frame.contentWindow.postMessage({ type: "videopoker", id: 1, method: "deal" }, "*")
+//	frame.contentWindow.postMessage({ type: "videopoker", id: 2, method: "hold", args: [1, 3, 5] }, "*")
+//	frame.contentWindow.postMessage({ type: "videopoker", method: "subscribe", args: ["won"] }, "*")
+//
+// The game answers each message with { type: "videopoker", id: ..., result: ... } or
+// { type: "videopoker", id: ..., error: "..." }. After "subscribe" (with no args for all of them),
+// events are posted to the host page as { type: "videopoker", event: ... } objects like the ones above.
+// Only messages from the page the game is embedded in are used, and the answers and events
+// are only sent to that page's origin.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"syscall/js"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+type embed_event struct {
+	Event string		`json:"event"`
+	Hand string		`json:"hand,omitempty"`
+	HandType *int		`json:"handType,omitempty"`
+	Win *int		`json:"win,omitempty"`
+	State vp.Snapshot	`json:"state"`
+}
+
+// Listeners added with vp.on(), by event name
+
+var listeners map[string][]js.Value = map[string][]js.Value{}
+
+// The host page's origin, and the events it subscribed to with postMessage
+
+var host_origin string
+var host_events map[string]bool
+
+// The result of the hand that was just drawn, until the "drawn" event is sent.
+// (The engine scores the hand before it's done with the draw. See dom_view in main.go.)
+
+var drawn_hand int = -1
+var drawn_win int
+
+func embed_result(handtype int) {
+	drawn_hand = handtype
+	drawn_win = vp.Payout(handtype, vp.BetMultiplier())
+}
+
+// Send events when the state changes, after the cards are shown
+
+func embed_state(state int) {
+	if state == vp.Draw {
+		emit(embed_event{ Event: "dealt", State: vp.GetState() })
+	}
+	if drawn_hand >= 0 && state != vp.Draw {
+		h, w := drawn_hand, drawn_win
+		e := embed_event{ Event: "drawn", Hand: vp.HandName(h), HandType: &h, Win: &w, State: vp.GetState() }
+		emit(e)
+		if h != vp.NOTHING {
+			e.Event = "won"
+			emit(e)
+		}
+		drawn_hand = -1
+	}
+}
+
+func emit(e embed_event) {
+	b, err := json.Marshal(e)
+	if err != nil { return }
+
+	later(func() {
+		data := js.Global().Get("JSON").Call("parse", string(b))
+		for _, f := range listeners[e.Event] { f.Invoke(data) }
+
+		if host_origin != "" && host_events[e.Event] {
+			data.Set("type", "videopoker")
+			js.Global().Get("parent").Call("postMessage", data, host_origin)
+		}
+	})
+}
+
+// Do a command for vp.deal(), etc., or a message from the host page
+
+func embed_command(method string, args []js.Value) (interface{}, error) {
+	var words []string
+
+	if method == "getState" {
+		b, err := json.Marshal(vp.GetState())
+		return string(b), err
+	}
+
+	if watch_id != "" { return nil, fmt.Errorf("spectators can't play") }
+	if busy() { return nil, fmt.Errorf("busy: wait for the cards to be dealt") }
+
+	// the arguments can be numbers or strings
+	var strs []string
+	for _, a := range args { strs = append(strs, js.Global().Get("String").Invoke(a).String()) }
+	if remote { return nil, remote_command(method, strs) }
+
+	switch method {
+		case "deal", "draw", "quit":
+			words = []string{ method }
+		case "newSession":
+			words = []string{ "new" }
+		case "hold", "bet", "game":
+			words = append([]string{ method }, strs...)
+		default:
+			return nil, fmt.Errorf("unknown method %q", method)
+	}
+	return nil, vp.Command(strings.Join(words, " "))
+}
+
+// A JavaScript function for a command
+
+func embed_method(method string) js.Func {
+	return js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result, err := embed_command(method, args)
+		if err != nil { return err.Error() }
+		return result
+	})
+}
+
+// vp.on("won", f) and vp.off("won", f)
+
+func embed_on(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 || args[1].Type() != js.TypeFunction { return nil }
+	listeners[args[0].String()] = append(listeners[args[0].String()], args[1])
+	return nil
+}
+
+func embed_off(this js.Value, args []js.Value) interface{} {
+	if len(args) < 2 { return nil }
+	name := args[0].String()
+	for i, f := range listeners[name] {
+		if f.Equal(args[1]) {
+			listeners[name] = append(listeners[name][:i], listeners[name][i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// Messages from the host page:
+//	{ type: "videopoker", id: 1, method: "hold", args: [1, 3, 5] }
+
+func receive_message(this js.Value, args []js.Value) interface{} {
+	event := args[0]
+	parent := js.Global().Get("parent")
+
+	// only the page the game is embedded in can control it
+	if parent.Equal(js.Global().Get("window")) || !event.Get("source").Equal(parent) { return nil }
+
+	data := event.Get("data")
+	if data.Type() != js.TypeObject || data.Get("type").String() != "videopoker" { return nil }
+
+	host_origin = event.Get("origin").String()
+	if host_origin == "null" { host_origin = "*" }	// a page from a file: URL, or a sandboxed frame
+
+	method := data.Get("method").String()
+	var margs []js.Value
+	if a := data.Get("args"); a.Type() == js.TypeObject {
+		for i := 0; i < a.Length(); i++ { margs = append(margs, a.Index(i)) }
+	}
+
+	var result interface{}
+	var err error
+	switch method {
+		case "subscribe":
+			host_events = map[string]bool{}
+			if len(margs) == 0 { margs = []js.Value{ js.ValueOf("dealt"), js.ValueOf("drawn"), js.ValueOf("won") } }
+			for _, a := range margs { host_events[a.String()] = true }
+		case "unsubscribe":
+			host_events = nil
+		default:
+			result, err = embed_command(method, margs)
+	}
+
+	reply := map[string]interface{} { "type": "videopoker", "id": data.Get("id") }
+	if err != nil {
+		reply["error"] = err.Error()
+	} else {
+		reply["result"] = result
+	}
+	parent.Call("postMessage", reply, host_origin)
+	return nil
+}
+
+func register_embed_callbacks() {
+	js.Global().Set("vp", map[string]interface{} {
+		"cmd":        js.FuncOf(console_command),	// see main.go
+		"getState":   embed_method("getState"),
+		"deal":       embed_method("deal"),
+		"draw":       embed_method("draw"),
+		"hold":       embed_method("hold"),
+		"bet":        embed_method("bet"),
+		"game":       embed_method("game"),
+		"quit":       embed_method("quit"),
+		"newSession": embed_method("newSession"),
+		"on":         js.FuncOf(embed_on),
+		"off":        js.FuncOf(embed_off),
+	})
+	js.Global().Call("addEventListener", "message", js.FuncOf(receive_message))
+}
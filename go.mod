@@ -0,0 +1,3 @@
+module github.com/Yaoir/VideoPoker-Go-WebAssembly
+
+go 1.23
@@ -0,0 +1,310 @@
+//go:build js && wasm
+
+// Key bindings for Video Poker
+
+// Keys are matched on the event.code property of keyboard events, which names
+// the physical key (like "KeyJ" or "Semicolon") rather than the character it types.
+// That way the bindings stay in the same place on the keyboard with any keyboard layout.
+// When the Shift key is held down, "Shift+" is put in front of the code.
+
+// The bindings can be changed in the Settings panel, and are saved in the
+// browser's localStorage, so they are still there when the page is reloaded.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"syscall/js"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+// The actions that keys can be bound to, in the order they are listed in the Settings panel
+
+type key_binding_action struct {
+	name string	// the name used in the bindings
+	label string	// the description shown in the Settings panel, with the chips for the bets
+}
+
+var actions []key_binding_action = []key_binding_action {
+	{ "hold1", "Hold card 1" },
+	{ "hold2", "Hold card 2" },
+	{ "hold3", "Hold card 3" },
+	{ "hold4", "Hold card 4" },
+	{ "hold5", "Hold card 5" },
+	{ "deal", "Deal / Draw" },
+	{ "bet1", "Bet %s" },
+	{ "bet2", "Bet %s" },
+	{ "bet3", "Bet %s" },
+	{ "bet4", "Bet %s" },
+	{ "bet5", "Bet %s (maximum)" },
+	{ "betone", "Bet one more" },
+	{ "quit", "Quit" },
+	{ "contrast", "High contrast on/off" },
+	{ "mute", "Sound on/off" },
+	{ "game0", "All American" },
+	{ "game1", "Tens or Better" },
+	{ "game2", "Bonus Poker" },
+	{ "game3", "Double Bonus" },
+	{ "game4", "Double Bonus Bonus" },
+	{ "game5", "Jacks or Better" },
+	{ "game6", "9/5 Jacks or Better" },
+	{ "game7", "8/6 Jacks or Better" },
+	{ "game8", "8/5 Jacks or Better" },
+	{ "game9", "7/5 Jacks or Better" },
+	{ "game10", "6/5 Jacks or Better" },
+}
+
+// Keys for changing the game, which are the same in all of the presets
+
+var game_keys map[string]string = map[string]string {
+	"Shift+KeyA": "game0",
+	"Shift+KeyB": "game1",
+	"Shift+KeyC": "game2",
+	"Shift+KeyD": "game3",
+	"Shift+KeyE": "game4",
+	"Shift+KeyF": "game5",
+	"Shift+KeyG": "game6",
+	"Shift+KeyH": "game7",
+	"Shift+KeyI": "game8",
+}
+
+// Preset key binding schemes.
+// "home" is the original scheme, with the fingers on the home row of the keyboard.
+// "casino" is like the buttons on a video poker machine.
+
+var presets map[string]map[string]string = map[string]map[string]string {
+	"home": {
+		"Space": "hold1",
+		"KeyJ": "hold2",
+		"KeyK": "hold3",
+		"KeyL": "hold4",
+		"Semicolon": "hold5",
+		"Enter": "deal",
+		"NumpadEnter": "deal",
+		"Digit1": "bet1",
+		"Digit2": "bet2",
+		"Digit3": "bet3",
+		"Digit4": "bet4",
+		"Digit5": "bet5",
+		"KeyQ": "quit",
+		"KeyE": "quit",
+		"KeyH": "contrast",
+		"KeyS": "mute",
+	},
+	"casino": {
+		"Digit1": "hold1",
+		"Digit2": "hold2",
+		"Digit3": "hold3",
+		"Digit4": "hold4",
+		"Digit5": "hold5",
+		"Numpad1": "hold1",
+		"Numpad2": "hold2",
+		"Numpad3": "hold3",
+		"Numpad4": "hold4",
+		"Numpad5": "hold5",
+		"Enter": "deal",
+		"NumpadEnter": "deal",
+		"Space": "deal",
+		"KeyB": "betone",
+		"KeyM": "bet5",
+		"KeyQ": "quit",
+		"KeyH": "contrast",
+		"KeyS": "mute",
+	},
+}
+
+const default_preset = "home"
+
+// name of the localStorage item the bindings are saved in
+
+const bindings_storage = "videopoker.keys"
+
+// The key bindings in use: event.code -> action
+
+var bindings map[string]string
+
+// When the player clicks on a Change button in the Settings panel,
+// this is set to the action, and the next key typed is bound to it.
+
+var rebinding string
+
+// Set the bindings to one of the presets
+
+func set_preset(name string) {
+	preset, ok := presets[name]
+	if !ok { return }
+
+	bindings = make(map[string]string)
+	for code, action := range preset { bindings[code] = action }
+	for code, action := range game_keys { bindings[code] = action }
+}
+
+// Load the saved bindings, or use the default preset if there aren't any
+
+func load_bindings() {
+	set_preset(default_preset)
+
+	saved := js.Global().Get("localStorage").Call("getItem", bindings_storage)
+	if saved.IsNull() { return }
+
+	var b map[string]string
+	if err := json.Unmarshal([]byte(saved.String()), &b); err != nil {
+		fmt.Printf("Ignoring saved key bindings: %v\n", err)
+		return
+	}
+	if b == nil { return }	// "null" was saved
+	bindings = b
+}
+
+func save_bindings() {
+	b, err := json.Marshal(bindings)
+	if err != nil { return }
+	js.Global().Get("localStorage").Call("setItem", bindings_storage, string(b))
+}
+
+// Do what a key is bound to
+
+func do_action(action string) {
+	var n int
+
+	switch {
+		case action == "deal":
+			play_deal_or_draw()
+		case action == "quit":
+			play_quit()
+		case action == "contrast":
+			switch_contrast()
+		case action == "mute":
+			switch_mute()
+		case action == "betone":
+			// cycle through the bets, like the Bet One button on a video poker machine
+			play_bet(vp.BetMultiplier() % 5 + 1)
+		case strings.HasPrefix(action, "hold"):
+			fmt.Sscanf(action, "hold%d", &n)
+			if n >= 1 && n <= vp.CARDS { vp.ToggleHold(n-1) }
+		case strings.HasPrefix(action, "bet"):
+			fmt.Sscanf(action, "bet%d", &n)
+			if n >= 1 && n <= 5 { play_bet(n) }
+		case strings.HasPrefix(action, "game"):
+			fmt.Sscanf(action, "game%d", &n)
+			play_game(n)
+	}
+}
+
+// The code for a keyboard event, with "Shift+" in front if the Shift key is down
+
+func event_code(event js.Value) string {
+	code := event.Get("code").String()
+	if event.Get("shiftKey").Bool() { code = "Shift+" + code }
+	return code
+}
+
+// The keys bound to an action, for showing in the Settings panel
+
+func keys_for(action string) string {
+	var keys []string
+
+	for code, a := range bindings {
+		if a == action { keys = append(keys, code) }
+	}
+	if len(keys) == 0 { return vp.Tr("(none)") }
+	sort.Strings(keys)
+	return strings.Join(keys, ", ")
+}
+
+// Bind a key to an action, replacing the action's other keys
+
+func rebind(code, action string) {
+	for c, a := range bindings {
+		if a == action { delete(bindings, c) }
+	}
+	bindings[code] = action
+	save_bindings()
+}
+
+// Fill in the key bindings table in the Settings panel
+
+func GUI_update_bindings() {
+	document := js.Global().Get("document")
+	table := document.Call("getElementById", "bindings")
+	table.Set("textContent", "")
+
+	for _, a := range actions {
+		row := document.Call("createElement", "tr")
+
+		label := document.Call("createElement", "td")
+		text := vp.Tr(a.label)
+		var n int
+		if _, err := fmt.Sscanf(a.name, "bet%d", &n); err == nil { text = fmt.Sprintf(text, vp.Number(n * vp.MinBet())) }
+		label.Set("textContent", text)
+		row.Call("appendChild", label)
+
+		keys := document.Call("createElement", "td")
+		if a.name == rebinding {
+			keys.Set("textContent", vp.Tr("Type a key..."))
+		} else {
+			keys.Set("textContent", keys_for(a.name))
+		}
+		row.Call("appendChild", keys)
+
+		change := document.Call("createElement", "td")
+		button := document.Call("createElement", "button")
+		button.Set("textContent", vp.Tr("Change"))
+		button.Call("setAttribute", "onclick", fmt.Sprintf("rebind(%q);", a.name))
+		change.Call("appendChild", button)
+		row.Call("appendChild", change)
+
+		table.Call("appendChild", row)
+	}
+}
+
+// Callbacks for the Settings panel
+
+// Show or hide the panel
+
+func toggle_settings(this js.Value, args []js.Value) interface{} {
+	js.Global().Get("document").Get("activeElement").Call("blur")
+	panel := js.Global().Get("document").Call("getElementById", "settings")
+	if panel.Get("style").Get("display").String() == "block" {
+		panel.Get("style").Set("display", "none")
+		rebinding = ""
+	} else {
+		GUI_update_bindings()
+		panel.Get("style").Set("display", "block")
+	}
+	return nil
+}
+
+// Choose a preset: keypreset("casino")
+
+func choose_preset(this js.Value, args []js.Value) interface{} {
+	// give the keyboard back to the game
+	js.Global().Get("document").Get("activeElement").Call("blur")
+
+	if len(args) < 1 { return nil }
+	set_preset(args[0].String())
+	save_bindings()
+	GUI_update_bindings()
+	return nil
+}
+
+// Click on a Change button: rebind("hold1")
+// The next key typed is bound to the action. (See key() in main.go)
+
+func start_rebind(this js.Value, args []js.Value) interface{} {
+	js.Global().Get("document").Get("activeElement").Call("blur")
+	if len(args) < 1 { return nil }
+	rebinding = args[0].String()
+	GUI_update_bindings()
+	return nil
+}
+
+func register_key_callbacks() {
+	js.Global().Set("settings", js.FuncOf(toggle_settings))
+	js.Global().Set("keypreset", js.FuncOf(choose_preset))
+	js.Global().Set("rebind", js.FuncOf(start_rebind))
+}
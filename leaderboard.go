@@ -0,0 +1,203 @@
+//go:build !js
+
+// Leaderboards, for sessions played on the server
+//
+// When a session played with the JSON API (see apiserver.go) ends, the server
+// records it in a file, one line of JSON for each session, and the player is told
+// where they ranked. The file is only added to, so it's safe to copy while the
+// server is running, and it's read back in when the server starts.
+//
+//	GET /api/leaderboard?variant=JacksOrBetter&window=week&limit=10
+//
+// The window is day, week, month or all (the default). Sessions are ranked by
+// the chips they ended with, then by the most chips they had, then by who got there first.
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+)
+
+type score_record struct {
+	Name string	`json:"name"`
+	Variant string	`json:"variant"`
+	Hands int	`json:"hands"`
+	Final int	`json:"final"`	// chips at the end
+	Peak int	`json:"peak"`	// the most chips during the session
+	Time time.Time	`json:"time"`
+}
+
+type ranked_record struct {
+	Rank int	`json:"rank"`
+	score_record
+}
+
+type leaderboard struct {
+	lock sync.Mutex
+	file *os.File
+	records []score_record
+}
+
+// The leaderboard, or nil if sessions aren't recorded (see webserver.go)
+
+var scores *leaderboard
+
+var windows map[string]time.Duration = map[string]time.Duration {
+	"day":   24 * time.Hour,
+	"week":  7 * 24 * time.Hour,
+	"month": 30 * 24 * time.Hour,
+	"all":   0,
+}
+
+const max_name = 20
+const default_name = "Anonymous"
+
+// Read the records in a file, and open it for adding more
+
+func open_leaderboard(path string) (*leaderboard, error) {
+	lb := &leaderboard{}
+
+	if f, err := os.Open(path); err == nil {
+		in := bufio.NewScanner(f)
+		for line := 1; in.Scan(); line++ {
+			var r score_record
+			if err := json.Unmarshal(in.Bytes(), &r); err != nil {
+				// a line cut off when the server stopped, or something like that
+				slog.Warn("skipping a leaderboard record", "file", path, "line", line, "error", err)
+				continue
+			}
+			lb.records = append(lb.records, r)
+		}
+		f.Close()
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil { return nil, err }
+	lb.file = f
+	return lb, nil
+}
+
+// Write out what has been added, and close the file. This is done when the server stops.
+
+func (lb *leaderboard) close() error {
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+	if err := lb.file.Sync(); err != nil {
+		lb.file.Close()
+		return err
+	}
+	return lb.file.Close()
+}
+
+// Sessions are ranked by the chips they ended with, then by the peak,
+// then by which was first
+
+func better(a, b score_record) bool {
+	if a.Final != b.Final { return a.Final > b.Final }
+	if a.Peak != b.Peak { return a.Peak > b.Peak }
+	return a.Time.Before(b.Time)
+}
+
+// Record a session. Returns its rank among all of the sessions of its variant,
+// and the number of them.
+
+func (lb *leaderboard) add(r score_record) (int, int, error) {
+	b, err := json.Marshal(r)
+	if err != nil { return 0, 0, err }
+
+	lb.lock.Lock()
+	defer lb.lock.Unlock()
+
+	// one write for each line, so the lines don't get mixed up
+	if _, err := lb.file.Write(append(b, '\n')); err != nil { return 0, 0, err }
+	if err := lb.file.Sync(); err != nil { return 0, 0, err }
+	lb.records = append(lb.records, r)
+
+	rank, total := 1, 0
+	for _, other := range lb.records {
+		if other.Variant != r.Variant { continue }
+		total++
+		if better(other, r) { rank++ }
+	}
+	return rank, total, nil
+}
+
+// The best sessions of a variant since a time (or all of them, for a zero time)
+
+func (lb *leaderboard) top(variant string, since time.Time, limit int) []ranked_record {
+	var list []score_record
+
+	lb.lock.Lock()
+	for _, r := range lb.records {
+		if r.Variant == variant && !r.Time.Before(since) { list = append(list, r) }
+	}
+	lb.lock.Unlock()
+
+	sort.SliceStable(list, func(i, j int) bool { return better(list[i], list[j]) })
+	if len(list) > limit { list = list[:limit] }
+
+	ranked := make([]ranked_record, len(list))
+	for i, r := range list { ranked[i] = ranked_record{ i+1, r } }
+	return ranked
+}
+
+// The name a player gave, cleaned up for showing to other players
+
+func player_name(name string) string {
+	name = strings.Join(strings.Fields(name), " ")
+	if r := []rune(name); len(r) > max_name { name = string(r[:max_name]) }
+	if name == "" { name = default_name }
+	return name
+}
+
+// GET /api/leaderboard
+
+func api_leaderboard(w http.ResponseWriter, r *http.Request) {
+	if scores == nil {
+		api_error(w, http.StatusNotFound, "there is no leaderboard")
+		return
+	}
+
+	q := r.URL.Query()
+	g := vp.JacksOrBetter
+	if v := q.Get("variant"); v != "" {
+		var err error
+		if g, err = vp.FindGame(v); err != nil {
+			api_error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	window := q.Get("window")
+	if window == "" { window = "all" }
+	length, ok := windows[window]
+	if !ok {
+		api_error(w, http.StatusBadRequest, "window must be day, week, month or all")
+		return
+	}
+	limit := 10
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 && l <= 100 { limit = l }
+
+	var since time.Time
+	if length > 0 { since = time.Now().Add(-length) }
+
+	variant := vp.GameID(g)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{} {
+		"variant": variant,
+		"window": window,
+		"entries": scores.top(variant, since, limit),
+	})
+}
@@ -0,0 +1,125 @@
+//go:build !js
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+)
+
+func TestLeaderboard(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leaderboard.jsonl")
+	lb, err := open_leaderboard(path)
+	if err != nil { t.Fatal(err) }
+
+	now := time.Now().UTC()
+	adds := []struct {
+		r score_record
+		rank, total int
+	}{
+		{ score_record{ Name: "a", Variant: "JacksOrBetter", Final: 1000, Peak: 1200, Time: now.Add(-40 * 24 * time.Hour) }, 1, 1 },
+		{ score_record{ Name: "b", Variant: "JacksOrBetter", Final: 1500, Peak: 1500, Time: now.Add(-2 * time.Hour) }, 1, 2 },
+		{ score_record{ Name: "c", Variant: "JacksOrBetter", Final: 1000, Peak: 1100, Time: now.Add(-time.Hour) }, 3, 3 },
+		{ score_record{ Name: "d", Variant: "BonusPoker", Final: 0, Peak: 1000, Time: now }, 1, 1 },
+	}
+	for _, a := range adds {
+		rank, total, err := lb.add(a.r)
+		if err != nil || rank != a.rank || total != a.total {
+			t.Errorf("add %s: rank %d of %d (%v), want %d of %d", a.r.Name, rank, total, err, a.rank, a.total)
+		}
+	}
+
+	names := func(list []ranked_record) string {
+		var s []string
+		for _, r := range list { s = append(s, r.Name) }
+		return strings.Join(s, " ")
+	}
+	if got := names(lb.top("JacksOrBetter", time.Time{}, 10)); got != "b a c" {
+		t.Errorf("all time: %q, want \"b a c\"", got)
+	}
+	if got := names(lb.top("JacksOrBetter", now.Add(-windows["day"]), 10)); got != "b c" {
+		t.Errorf("today: %q, want \"b c\"", got)
+	}
+	if got := names(lb.top("JacksOrBetter", time.Time{}, 1)); got != "b" {
+		t.Errorf("limit 1: %q, want \"b\"", got)
+	}
+	lb.file.Close()
+
+	/* the records are read back in, and a line that was cut off is skipped */
+	f, _ := os.OpenFile(path, os.O_WRONLY|os.O_APPEND, 0644)
+	f.WriteString(`{"name":"cut off","vari`)
+	f.Close()
+	lb, err = open_leaderboard(path)
+	if err != nil { t.Fatal(err) }
+	defer lb.file.Close()
+	if len(lb.records) != len(adds) { t.Errorf("read %d records, want %d", len(lb.records), len(adds)) }
+	if got := names(lb.top("BonusPoker", time.Time{}, 10)); got != "d" {
+		t.Errorf("after reading: %q, want \"d\"", got)
+	}
+
+	if got := player_name("  Lucky \n  Lou  "); got != "Lucky Lou" { t.Errorf("player_name: %q", got) }
+	if got := player_name(""); got != default_name { t.Errorf("player_name(\"\"): %q", got) }
+	if got := player_name(strings.Repeat("é", 30)); len([]rune(got)) != max_name { t.Errorf("long player_name: %q", got) }
+}
+
+func TestLeaderboardAPI(t *testing.T) {
+	var err error
+
+	vp.Console = io.Discard
+	if scores, err = open_leaderboard(filepath.Join(t.TempDir(), "leaderboard.jsonl")); err != nil { t.Fatal(err) }
+	defer func() { scores.file.Close(); scores = nil }()
+
+	mux := http.NewServeMux()
+	register_api(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	_, resp := api_post(t, server, "/api/session", `{"variant":"DoubleBonus","name":"Kim"}`)
+	id := "/api/session/" + resp.ID
+	api_post(t, server, id + "/deal", ``)
+	api_post(t, server, id + "/draw", `{"holds":[false,false,false,false,false]}`)
+
+	status, resp := api_post(t, server, id + "/quit", ``)
+	if status != http.StatusOK || resp.Rank != 1 || resp.Total != 1 {
+		t.Fatalf("quit: %d %+v", status, resp)
+	}
+	var summary []string
+	for _, c := range resp.Calls {
+		if c.Method == "Summary" { summary = c.Lines }
+	}
+	if len(summary) == 0 || summary[len(summary)-1] != "Rank: 1 of 1" {
+		t.Errorf("summary: %q", summary)
+	}
+
+	/* quitting again doesn't record the session twice */
+	if status, resp = api_post(t, server, id + "/quit", ``); resp.Rank != 0 || len(scores.records) != 1 {
+		t.Errorf("second quit: %d %+v", status, resp)
+	}
+
+	r, err := http.Get(server.URL + "/api/leaderboard?variant=DoubleBonus&window=week")
+	if err != nil { t.Fatal(err) }
+	var board struct {
+		Variant string
+		Entries []ranked_record
+	}
+	json.NewDecoder(r.Body).Decode(&board)
+	r.Body.Close()
+	if board.Variant != "DoubleBonus" || len(board.Entries) != 1 || board.Entries[0].Name != "Kim" || board.Entries[0].Hands != 1 {
+		t.Errorf("leaderboard: %+v", board)
+	}
+
+	for _, q := range []string{ "variant=NoSuchGame", "window=year" } {
+		if r, err := http.Get(server.URL + "/api/leaderboard?" + q); err != nil || r.StatusCode != http.StatusBadRequest {
+			t.Errorf("leaderboard?%s: %v %v", q, r.Status, err)
+		}
+	}
+}
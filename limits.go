@@ -0,0 +1,123 @@
+//go:build js && wasm
+
+// Limits for playing responsibly, set in the Settings panel
+//
+// The player can set a loss limit, a win goal, a time limit, and how often to have a
+// reality check (see videopoker/limits.go). When play stops at one of them, a dialog
+// covers the game until the player clicks OK.
+//
+// The limits, the time and the chips counted so far, and whether play has stopped
+// are kept in localStorage after each hand, so reloading the page doesn't reset them.
+// In remote mode (see remote.go), they are sent to the server when the session starts,
+// and the server checks them, so reloading the page with ?remote doesn't get around
+// them either. Changing them and clicking OK are sent to the server too, and each
+// answer has the counts, which are kept in localStorage as before.
+
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"syscall/js"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+const limits_storage = "videopoker.limits"
+
+// The inputs in the Settings panel, and the limits they're for
+
+var limit_inputs []string = []string { "losslimit", "wingoal", "maxminutes", "checkminutes" }
+
+func limit_fields(l *vp.Limits) []*int {
+	return []*int{ &l.LossLimit, &l.WinGoal, &l.MaxMinutes, &l.CheckMinutes }
+}
+
+func save_limits() {
+	b, err := json.Marshal(vp.GetLimits())
+	if err != nil { return }
+	js.Global().Get("localStorage").Call("setItem", limits_storage, string(b))
+}
+
+func load_limits() {
+	var l vp.LimitState
+
+	saved := js.Global().Get("localStorage").Call("getItem", limits_storage)
+	if saved.IsNull() || json.Unmarshal([]byte(saved.String()), &l) != nil { return }
+	vp.RestoreLimits(l)
+	show_limits()
+}
+
+// Show the limits in the Settings panel
+
+func show_limits() {
+	l := vp.GetLimits().Limits
+	document := js.Global().Get("document")
+	for i, n := range limit_fields(&l) {
+		value := ""
+		if *n > 0 { value = strconv.Itoa(*n) }
+		document.Call("getElementById", limit_inputs[i]).Set("value", value)
+	}
+}
+
+// Callback for the limit inputs in the Settings panel. Empty inputs, or 0, are no limit.
+// While play is stopped at a limit, they can't be raised or removed.
+
+func set_limits(this js.Value, args []js.Value) interface{} {
+	var l vp.Limits
+
+	document := js.Global().Get("document")
+	for i, n := range limit_fields(&l) {
+		input := document.Call("getElementById", limit_inputs[i])
+		v, err := strconv.Atoi(input.Get("value").String())
+		if err != nil || v < 0 { v = 0 }
+		if v == 0 { input.Set("value", "") }
+		*n = v
+	}
+	if remote {
+		remote_do("limits", map[string]interface{} { "limits": l })
+		return nil
+	}
+	if err := vp.SetLimits(l); err != nil {
+		show_limits()
+		GUI_update_message(err.Error())
+		return nil
+	}
+	save_limits()
+	return nil
+}
+
+// Show the dialog when play stops, or hide it
+
+func GUI_limit(msg string) {
+	later(func() {
+		document := js.Global().Get("document")
+		dialog := document.Call("getElementById", "limit")
+		if msg == "" {
+			dialog.Set("style", "display: none;")
+			return
+		}
+		document.Call("getElementById", "limitmessage").Set("textContent", msg)
+		dialog.Set("style", "display: block;")
+		document.Call("getElementById", "limitbutton").Call("focus")
+	})
+	save_limits()
+}
+
+// Callback for the OK button in the dialog
+
+func acknowledge_limit(this js.Value, args []js.Value) interface{} {
+	if drawing() { return nil }
+	if remote {
+		if vp.LimitStopped() && !remote_waiting { remote_do("acknowledge", nil) }
+		return nil
+	}
+	vp.AcknowledgeLimit()
+	save_limits()
+	return nil
+}
+
+func register_limit_callbacks() {
+	js.Global().Set("setlimits", js.FuncOf(set_limits))
+	js.Global().Set("acknowledge", js.FuncOf(acknowledge_limit))
+}
@@ -0,0 +1,208 @@
+//go:build js && wasm
+
+// Watching a game on the server while it's played
+//
+// In remote mode (see remote.go), the page shows a link for watching the session,
+// like http://localhost:8080/?watch=<watch id>, which the player can give to others.
+// The page that's opened with the link is a spectator's: it shows the game as the
+// player plays it, with a WebSocket to the server (see spectate.go), and input is
+// ignored (see busy() in main.go). Spectators also see the best cards to hold,
+// outlined, while the player decides, so a coach can help, and after the draw, how
+// much the player's hold paid back on average compared to the best one. The player's
+// page doesn't get those, since it uses the session's id, not the watch id.
+//
+// Spectators can type comments, which are shown in the player's message line.
+// The player's page has a WebSocket too, to get the comments and to send the holds
+// as they're changed, so spectators see them before the draw.
+// The spectator's name is given with ?name= in the URL, as for the leaderboard.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"syscall/js"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+var watch_id string		// the session being watched, from ?watch= in the URL
+var live_socket js.Value	// the WebSocket to the server, or undefined
+var live_sent []bool		// the holds the spectators were sent last
+
+type live_message struct {
+	Type string		`json:"type"`
+	State *vp.Snapshot	`json:"state"`
+	Calls []vp.Call		`json:"calls"`
+	Dealt []string		`json:"dealt"`
+	Best []bool		`json:"best"`
+	BestValue float64	`json:"bestValue"`
+	HeldValue *float64	`json:"heldValue"`
+	Holds []bool		`json:"holds"`
+	Name string		`json:"name"`
+	Text string		`json:"text"`
+	Spectators int		`json:"spectators"`
+}
+
+// Open a WebSocket to the server. on_message is called with each message,
+// and on_close when it's closed.
+
+func live_open(path string, on_message func(live_message), on_close func()) {
+	location := js.Global().Get("location")
+	u := js.Global().Get("URL").New(path, location.Get("href"))
+	if location.Get("protocol").String() == "https:" { u.Set("protocol", "wss:") } else { u.Set("protocol", "ws:") }
+
+	live_socket = js.Global().Get("WebSocket").New(u.Call("toString"))
+	live_socket.Set("onmessage", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		var msg live_message
+		if err := json.Unmarshal([]byte(args[0].Get("data").String()), &msg); err != nil {
+			fmt.Printf("Watching: %v\n", err)
+			return nil
+		}
+		on_message(msg)
+		return nil
+	}))
+	live_socket.Set("onclose", js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		live_socket = js.Undefined()
+		on_close()
+		return nil
+	}))
+}
+
+func live_send(msg map[string]interface{}) {
+	if live_socket.IsUndefined() || live_socket.Get("readyState").Int() != 1 { return }	// not open
+	b, _ := json.Marshal(msg)
+	live_socket.Call("send", string(b))
+}
+
+// A percentage of the bet, like "97%"
+
+func percent(value float64) string {
+	return fmt.Sprintf(vp.Tr("%s%%"), vp.Number(int(value * 100 + 0.5)))
+}
+
+func show_comment(msg live_message) {
+	line := fmt.Sprintf("%s: %s", msg.Name, msg.Text)
+	GUI_update_message(line)
+	GUI_announce(line)
+}
+
+// The player's page: show the link for watching, and open the WebSocket
+
+func live_start(watch string) {
+	link := js.Global().Get("URL").New("?watch=" + url.QueryEscape(watch), js.Global().Get("location").Get("href")).Call("toString").String()
+	document := js.Global().Get("document")
+	a := document.Call("getElementById", "watchlink")
+	a.Set("href", link)
+	a.Set("textContent", link)
+	document.Call("getElementById", "share").Set("style", "display: block;")
+	fmt.Printf("Spectators can watch at %s\n", link)
+
+	if !live_socket.IsUndefined() { return }
+	live_open("api/session/" + remote_id + "/live", func(msg live_message) {
+		switch msg.Type {
+			case "comment": show_comment(msg)
+			case "spectators":
+				text := ""
+				if msg.Spectators > 0 { text = fmt.Sprintf(vp.Tr("%s watching"), vp.Number(msg.Spectators)) }
+				GUI_set_text("spectators", text)
+		}
+	}, func() {
+		GUI_set_text("spectators", "")
+	})
+}
+
+// Send the holds to the spectators when the player changes them
+
+func live_holds() {
+	if watch_id != "" || live_socket.IsUndefined() || vp.State() != vp.Draw { return }
+	holds := vp.GetState().Holds
+	if fmt.Sprint(holds) == fmt.Sprint(live_sent) { return }
+	live_sent = holds
+	live_send(map[string]interface{} { "holds": holds })
+}
+
+// The spectator's page
+
+func watch_start() {
+	document := js.Global().Get("document")
+	document.Call("getElementById", "coach").Set("style", "display: block;")
+	document.Call("getElementById", "choosegame").Set("style", "display: none;")
+	GUI_update_message(vp.Tr("Connecting to the game..."))
+	input := document.Call("getElementById", "comment")
+	input.Set("placeholder", vp.Tr("Comment for the player"))
+	input.Call("setAttribute", "aria-label", vp.Tr("Comment for the player"))
+	js.Global().Set("comment", js.FuncOf(send_comment))
+
+	path := "api/watch/" + url.PathEscape(watch_id)
+	if remote_name != "" { path += "?name=" + url.QueryEscape(remote_name) }
+	live_open(path, watch_message, func() {
+		later(func() {
+			show_best(nil)
+			GUI_update_message(vp.Tr("The game isn't being shown any more"))
+		})
+	})
+}
+
+func watch_message(msg live_message) {
+	switch msg.Type {
+		case "game":
+			if msg.State == nil { return }
+			remote_show(remote_response{ State: *msg.State, Calls: msg.Calls })
+			later(func() { show_best(msg.Best) })
+			switch {
+				case msg.Best != nil && msg.HeldValue != nil:
+					text := fmt.Sprintf(vp.Tr("The best hold was %s, which paid back %s on average, and the player's %s"),
+						hold_names(msg.Dealt, msg.Best), percent(msg.BestValue), percent(*msg.HeldValue))
+					later(func() { GUI_set_text("coaching", text) })
+				case msg.Best != nil:
+					text := fmt.Sprintf(vp.Tr("Best hold: %s, which pays back %s on average"),
+						hold_names(msg.State.Hand, msg.Best), percent(msg.BestValue))
+					later(func() { GUI_set_text("coaching", text) })
+			}
+		case "holds":
+			later(func() {
+				snap := vp.GetState()
+				if snap.Phase != "draw" || len(msg.Holds) != vp.CARDS { return }
+				snap.Holds = msg.Holds
+				vp.Load(snap)
+				for i := 0; i < vp.CARDS; i++ { GUI_update_hold(i) }
+			})
+		case "comment":
+			later(func() { show_comment(msg) })
+	}
+}
+
+// The cards to hold, like "Jh Js", from the hand that was dealt
+
+func hold_names(dealt []string, best []bool) string {
+	var names []string
+	for i, held := range best {
+		if held && i < len(dealt) { names = append(names, dealt[i]) }
+	}
+	if len(names) == 0 { return vp.Tr("none") }
+	return strings.Join(names, " ")
+}
+
+// Outline where the best cards to hold were, or none
+
+func show_best(best []bool) {
+	for i := 0; i < vp.CARDS; i++ {
+		card := js.Global().Get("document").Call("getElementById", fmt.Sprintf("card%d", i+1))
+		card.Get("classList").Call("toggle", "best", i < len(best) && best[i])
+	}
+	if best == nil { GUI_set_text("coaching", "") }
+}
+
+// Callback for the Send button and the Enter key in the comment box
+
+func send_comment(this js.Value, args []js.Value) interface{} {
+	input := js.Global().Get("document").Call("getElementById", "comment")
+	text := strings.TrimSpace(input.Get("value").String())
+	if text == "" { return nil }
+	live_send(map[string]interface{} { "comment": text })
+	input.Set("value", "")
+	return nil
+}
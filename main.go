@@ -1,5 +1,7 @@
+//go:build js && wasm
+
 // Video Poker - a single page web app in Go/WebAssembly
-// build: GOOS=js GOARCH=wasm go build -o main.wasm main.go videopoker-web.go
+// build: GOOS=js GOARCH=wasm go build -o main.wasm .
 
 // This program is written to be educational,
 // and is not always as efficient as it could be.
@@ -10,6 +12,9 @@ import (
 	"fmt"
 	"strconv"
 	"syscall/js"
+	"time"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
 	)
 
 // The generalized way to change the text content of an HTML element, identified by an id property in the HTML tag
@@ -25,7 +30,9 @@ func GUI_set_text(id, text string) {
 // code more self-documenting and simpler to build up
 
 func GUI_update_message(msg string) {
-	js.Global().Get("document").Call("getElementById", "message").Set("textContent", msg)
+	later(func() {
+		js.Global().Get("document").Call("getElementById", "message").Set("textContent", msg)
+	})
 }
 
 func GUI_update_gamename(name string) {
@@ -33,7 +40,9 @@ func GUI_update_gamename(name string) {
 }
 
 func GUI_update_handname(name string) {
-	js.Global().Get("document").Call("getElementById", "hand").Set("textContent", name)
+	later(func() {
+		js.Global().Get("document").Call("getElementById", "hand").Set("textContent", name)
+	})
 }
 
 // The generalized way to change the CSS style of an HTML element, identified by an id property in the HTML tag
@@ -55,27 +64,265 @@ func GUI_button_visible() {
 
 func GUI_update_button() {
 	var label string
-	if state == Draw { label = "Draw Cards" } else { label = "Deal New Hand" }
-	js.Global().Get("document").Call("getElementById", "drawbutton").Set("textContent", label)
+	switch vp.State() {
+		case vp.Draw: label = "Draw Cards"
+		case vp.Over: label = "Start New Session"
+		default:   label = "Deal New Hand"
+	}
+	label = vp.Tr(label)
+	later(func() {
+		js.Global().Get("document").Call("getElementById", "drawbutton").Set("textContent", label)
+	})
+}
+
+// The session summary, shown in place of the score line when the session ends.
+// Each line of the summary is put in its own <div>.
+
+func GUI_show_summary(lines ...string) {
+	later(func() { show_summary(lines) })
+}
+
+func show_summary(lines []string) {
+	document := js.Global().Get("document")
+	summary := document.Call("getElementById", "summary")
+	summary.Set("textContent", "")
+	for _, line := range lines {
+		div := document.Call("createElement", "div")
+		div.Set("textContent", line)
+		summary.Call("appendChild", div)
+	}
+	summary.Set("style", "display: block;")
+}
+
+func GUI_hide_summary() {
+	js.Global().Get("document").Call("getElementById", "summary").Set("style", "display: none;")
+}
+
+// The pay table, with a row for each winning hand in the game and a column for each bet from 1 to 5.
+// The column for the current bet is highlighted, and if win is a winning hand,
+// its row flashes. (Use vp.NOTHING for no winning hand.)
+// The table is rebuilt each time, since the game or bet may have changed.
+
+func GUI_update_paytable(win int) {
+	later(func() { update_paytable(win) })
+}
+
+func update_paytable(win int) {
+	document := js.Global().Get("document")
+	table := document.Call("getElementById", "paytable")
+	table.Set("textContent", "")
+
+	for i := vp.ROYAL; i < vp.NOTHING; i++ {
+		if vp.Payout(i, 1) == 0 { continue }	// not in this game
+		row := document.Call("createElement", "tr")
+		if i == win { row.Set("className", "win") }
+
+		name := document.Call("createElement", "td")
+		name.Set("className", "handname")
+		name.Set("textContent", vp.HandName(i))
+		row.Call("appendChild", name)
+
+		for m := 1; m <= 5; m++ {
+			pay := document.Call("createElement", "td")
+			if m == vp.BetMultiplier() { pay.Set("className", "bet") }
+			pay.Set("textContent", vp.Number(vp.Payout(i, m)))
+			row.Call("appendChild", pay)
+		}
+		table.Call("appendChild", row)
+	}
 }
 
-// Change the card images
+// Change the cards, using the card theme (see themes.go)
 
 // In JavaScript, this would be
-// document.getElementById(id).src = filename
-// to modify the <img src="{filename}"> property
+// document.getElementById(id).innerHTML = markup
+// to put an <img> or <svg> for the card inside <span id="card1"> (etc.)
 
 func GUI_update_hand() {
 	var i int
+	var reveal bool
+
 	for i = 0; i < 5; i++ {
+		// face down cards are turned over one at a time (see reveal_cards() below)
+		if facedown[i] { reveal = true; continue }
 		cardN := fmt.Sprintf("card%d",i+1)
-		filename := fmt.Sprintf("img/%s",hand[i].uc)
-		js.Global().Get("document").Call("getElementById", cardN).Set("src", filename)
+		js.Global().Get("document").Call("getElementById", cardN).Set("innerHTML", card_markup(vp.Hand()[i]))
+		GUI_update_card_label(i)
+	}
+
+	if reveal {
+		animating = true
+		go reveal_cards()
+	}
+}
+
+// Animation of dealing and drawing cards, and of the score counting up after a win.
+//
+// When a hand is dealt, the engine turns the cards face down with GUI_face_down(),
+// then GUI_update_hand() turns them face up again, from left to right, with a pause
+// between cards. While that happens, the other GUI_ functions that change what's shown
+// (the message, hand name, score, etc.) are queued by later(), and done after the animation,
+// so the result of the hand doesn't show up before the cards do.
+// Input is ignored while an animation is running. (See busy().)
+
+// The pause between cards for each animation speed, which is chosen in the Settings panel
+
+var anim_speeds map[string]time.Duration = map[string]time.Duration {
+	"off":    0,
+	"fast":   80 * time.Millisecond,
+	"normal": 180 * time.Millisecond,
+	"slow":   350 * time.Millisecond,
+}
+
+const default_speed = "normal"
+const speed_storage = "videopoker.speed"
+
+var anim_delay time.Duration = anim_speeds[default_speed]
+
+var animating bool		// true while an animation is running
+var anim_queue []func()		// what to do after the animation
+var facedown [vp.CARDS]bool	// cards waiting to be turned over
+
+// Do something now, or after the animation if one is running
+
+func later(f func()) {
+	if animating {
+		anim_queue = append(anim_queue, f)
+	} else {
+		f()
+	}
+}
+
+// The end of an animation. What was queued up is done now, unless it starts
+// another animation (like the score counting up), which does the rest when it ends.
+
+func end_animation() {
+	animating = false
+	for len(anim_queue) > 0 && !animating {
+		f := anim_queue[0]
+		anim_queue = anim_queue[1:]
+		f()
+	}
+}
+
+// True while the page is being changed, and shouldn't be redrawn
+
+func drawing() bool {
+	return animating || remote_waiting
+}
+
+// For event handlers: true if input should be ignored.
+// Spectators can't play at all (see live.go), and the player can't while autoplay runs (see autoplay.go).
+
+func busy() bool {
+	return drawing() || watch_id != "" || autoplaying
+}
+
+// Turn a card face down, to be turned over by GUI_update_hand()
+
+func GUI_face_down(n int) {
+	if anim_delay == 0 { return }
+	facedown[n] = true
+	span := js.Global().Get("document").Call("getElementById", fmt.Sprintf("card%d",n+1))
+	span.Set("innerHTML", nocard_markup)
+	span.Get("classList").Call("add", "facedown")
+	span.Get("classList").Call("remove", "reveal")
+	GUI_update_card_label(n)
+}
+
+// Turn the face down cards over, left to right
+
+func reveal_cards() {
+	for i := 0; i < vp.CARDS; i++ {
+		if !facedown[i] { continue }
+		time.Sleep(anim_delay)
+
+		span := js.Global().Get("document").Call("getElementById", fmt.Sprintf("card%d",i+1))
+		span.Get("style").Set("animationDuration", anim_delay.String())
+		span.Set("innerHTML", card_markup(vp.Hand()[i]))
+		span.Get("classList").Call("remove", "facedown")
+		span.Get("classList").Call("add", "reveal")
+		facedown[i] = false
+		GUI_update_card_label(i)
+		play("card")
+	}
+	end_animation()
+}
+
+// Count the score up to a win, taking about as long as dealing a hand
+
+func roll_score(from, to int) {
+	steps := 20
+	if to - from < steps { steps = to - from }
+	for i := 1; i <= steps; i++ {
+		time.Sleep(anim_delay * 5 / time.Duration(steps))
+		set_score(from + (to - from) * i / steps)
+		play("tick")
 	}
+	end_animation()
 }
 
+func set_animation_speed(speed string) {
+	delay, ok := anim_speeds[speed]
+	if !ok { return }
+	anim_delay = delay
+	js.Global().Get("localStorage").Call("setItem", speed_storage, speed)
+	js.Global().Get("document").Call("getElementById", "speed").Set("value", speed)
+}
+
+func load_animation_speed() {
+	saved := js.Global().Get("localStorage").Call("getItem", speed_storage)
+	if saved.IsNull() {
+		set_animation_speed(default_speed)
+	} else {
+		set_animation_speed(saved.String())
+	}
+}
+
+// Callback for the animation speed menu in the Settings panel: animspeed("fast")
+
+func choose_speed(this js.Value, args []js.Value) interface{} {
+	js.Global().Get("document").Get("activeElement").Call("blur")
+	if len(args) < 1 { return nil }
+	set_animation_speed(args[0].String())
+	return nil
+}
+
+// The label of a card, which is what a screen reader says for it,
+// like "ten of hearts, held". The held state is also in the aria-pressed attribute,
+// since the cards act like toggle buttons.
+
+func GUI_update_card_label(n int) {
+	span := js.Global().Get("document").Call("getElementById", fmt.Sprintf("card%d",n+1))
+	c := vp.Hand()[n]
+	label := c.Name()
+	if facedown[n] { label = vp.Tr("face down card") }
+	if vp.Held(n) { label = fmt.Sprintf(vp.Tr("%s, held"), label) }
+	span.Call("setAttribute", "aria-label", label)
+	span.Call("setAttribute", "aria-pressed", strconv.FormatBool(vp.Held(n)))
+}
+
+// The score counts up to a win when animations are on.
+// A win is when the score goes up while the hand is in the Draw state,
+// as opposed to starting a new session.
+
+var shown_score int = vp.INITCHIPS
+
 func GUI_update_score(score int) {
-	score_alpha := strconv.Itoa(score)
+	win := vp.State() == vp.Draw
+	later(func() {
+		if win && score > shown_score && anim_delay > 0 {
+			animating = true
+			go roll_score(shown_score, score)
+		} else {
+			set_score(score)
+		}
+	})
+}
+
+func set_score(score int) {
+	shown_score = score
+	score_alpha := vp.Number(score)
 	js.Global().Get("document").Call("getElementById", "score").Set("textContent", score_alpha)
 }
 
@@ -100,17 +347,19 @@ func hold_none () {
 
 // Callbacks for clicking on the cards
 
-// Hold or un-hold a card. This toggles when the card is clicked.
+// Show whether a card is held. The engine calls this (with View.Hold) when a card is clicked.
 
 func GUI_update_hold(n int) {
 	cardN := fmt.Sprintf("card%d",n+1)  // Card numbers in the HTML range from 1 to 5, not 0 to 4
-	if hold[n] == 1 {
+	if vp.Held(n) {
 		// set cardN style for holding the card
 		js.Global().Get("document").Call("getElementById", cardN).Set("style", css_card_hold)
 	} else {
 		// set cardN style for un-holding the card
 		js.Global().Get("document").Call("getElementById", cardN).Set("style", css_card_free)
 	}
+	GUI_update_card_label(n)
+	live_holds()	// for the spectators
 }
 
 // The following 5 functions are done very simplistically, and could also be
@@ -118,32 +367,32 @@ func GUI_update_hold(n int) {
 // function to get the card number from args[0]
 
 func hold1(this js.Value, args []js.Value) interface{} {
-	toggle_hold(0)
-	GUI_update_hold(0)
+	if busy() { return nil }
+	vp.ToggleHold(0)
 	return nil
 }
 
 func hold2(this js.Value, args []js.Value) interface{} {
-	toggle_hold(1)
-	GUI_update_hold(1)
+	if busy() { return nil }
+	vp.ToggleHold(1)
 	return nil
 }
 
 func hold3(this js.Value, args []js.Value) interface{} {
-	toggle_hold(2)
-	GUI_update_hold(2)
+	if busy() { return nil }
+	vp.ToggleHold(2)
 	return nil
 }
 
 func hold4(this js.Value, args []js.Value) interface{} {
-	toggle_hold(3)
-	GUI_update_hold(3)
+	if busy() { return nil }
+	vp.ToggleHold(3)
 	return nil
 }
 
 func hold5(this js.Value, args []js.Value) interface{} {
-	toggle_hold(4)
-	GUI_update_hold(4)
+	if busy() { return nil }
+	vp.ToggleHold(4)
 	return nil
 }
 
@@ -156,27 +405,86 @@ func deal_or_draw(this js.Value, args []js.Value) interface{} {
 	// The following does a this.blur() to avoid focus on the button.
 
 	js.Global().Get("document").Call("getElementById", "drawbutton").Call("blur")
-	key_action(byte('\r'))	// process it as a press of the Enter key, which does the same thing
+	if busy() { return nil }
+	play_deal_or_draw()
 	return nil
 }
 
-// Callbacks for change of game
-// (not implemented yet)
+// Callback for change of game, from the buttons in the Choose Game menu.
+// The game id (AllAmerican, TensOrBetter, etc. in videopoker/videopoker.go) is in args[0]:
+//	<button onclick="changegame(5);">
 
-func jacks_or_better(this js.Value, args []js.Value) interface{} {
-	// set game to Jacks or Better (default)
+func choose_game(this js.Value, args []js.Value) interface{} {
+	// As with the Deal/Draw button, take the focus away from the button that was
+	// clicked, so the space bar doesn't click it again.
+	js.Global().Get("document").Get("activeElement").Call("blur")
+
+	if len(args) < 1 || busy() { return nil }
+	play_game(args[0].Int())
 	return nil
 }
 
-// TODO: Callbacks for other change game functions,
-// or a changegame(n) function
+// The Choose Game menu buttons, in the same order as the game ids
+
+var gamebuttons [vp.NUMGAMES]string = [vp.NUMGAMES]string {
+	"AllAmericanButton",
+	"TensOrBetterButton",
+	"BonusPokerButton",
+	"DoubleBonusButton",
+	"DoubleBonusBonusButton",
+	"JacksOrBetterButton",
+	"JacksOrBetter95Button",
+	"JacksOrBetter86Button",
+	"JacksOrBetter85Button",
+	"JacksOrBetter75Button",
+	"JacksOrBetter65Button",
+}
+
+// Highlight the button of the game being played
 
-// key() is the callback event handler for keypress events.
+func GUI_update_gamemenu() {
+	for g := 0; g < vp.NUMGAMES; g++ {
+		class := "choosegame"
+		if g == vp.CurrentGame() { class = "choosegame active" }
+		js.Global().Get("document").Call("getElementById", gamebuttons[g]).Set("className", class)
+	}
+}
+
+// key() is the callback event handler for keydown events.
 // It is connected to the HTML in index.html like this:
-//	<body onkeypress="return key(event);">
+//	<body onkeydown="key(event);">
+//
+// The key is looked up in the key bindings (see keys.go) by its event.code,
+// so keys that aren't bound are left alone for the browser to handle.
 
 func key(this js.Value, arg []js.Value) interface{} {
-	var c rune
+	event := arg[0]
+
+	// Leave browser shortcuts like Ctrl-R alone,
+	// and don't take keys meant for the controls in the Settings panel.
+	if event.Get("ctrlKey").Bool() || event.Get("altKey").Bool() || event.Get("metaKey").Bool() {
+		return nil
+	}
+
+	// any other key stops autoplay
+	if autoplaying {
+		event.Call("stopPropagation")
+		event.Call("preventDefault")
+		stop_autoplay(vp.Tr("Autoplay stopped"))
+		return nil
+	}
+
+	target := event.Get("target")
+	switch target.Get("tagName").String() {
+		case "SELECT", "INPUT": return nil
+	}
+
+	code := event_code(event)
+
+	// Enter and Space click on a button that has the keyboard focus,
+	// and work on a card that has the focus (see access.go).
+	if target.Get("tagName").String() == "BUTTON" && (code == "Enter" || code == "Space") { return nil }
+	if card_key(event, target, code) { return nil }
 
 	// call event.preventDefault() and event.stopPropagation()
 	// to avoid the keyboard events triggering other things in the browser.
@@ -185,29 +493,167 @@ func key(this js.Value, arg []js.Value) interface{} {
 	// as clicking on the button, and in this app, the space bar is used for toggling selection
 	// of the leftmost card.
 
-	arg[0].Call("stopPropagation")
-	arg[0].Call("preventDefault")
+	// A key typed after clicking on a Change button in the Settings panel
+	// is bound to that action instead of doing something.
+	if rebinding != "" {
+		// wait for the key that goes with the Shift key
+		switch event.Get("key").String() {
+			case "Shift", "Control", "Alt", "Meta": return nil
+		}
+		event.Call("stopPropagation")
+		event.Call("preventDefault")
+		rebind(code, rebinding)
+		rebinding = ""
+		GUI_update_bindings()
+		return nil
+	}
+
+	action, ok := bindings[code]
+	if !ok { return nil }
+
+	event.Call("stopPropagation")
+	event.Call("preventDefault")
 
-	rs := []rune(arg[0].Get("key").String())
-	// IMPORTANT: The Enter/Return key shows up here as the string "Enter",
-	// so it gets converted here to a '\r'
-	if len(rs) == 1 {
-		c = rs[0]
+	// keys typed while the cards are being dealt are ignored
+	if busy() { return nil }
+
+	do_action(action)
+
+	return nil
+}
+
+// The language (see videopoker/messages.go) is chosen in the Settings panel, or if it's "auto",
+// from the languages the player has chosen in the browser. It's saved in localStorage.
+
+const lang_storage = "videopoker.lang"
+
+func set_language(setting string) {
+	if setting == "auto" {
+		var wanted []string
+		langs := js.Global().Get("navigator").Get("languages")
+		for i := 0; langs.Truthy() && i < langs.Length(); i++ {
+			wanted = append(wanted, langs.Index(i).String())
+		}
+		if len(wanted) == 0 { wanted = append(wanted, js.Global().Get("navigator").Get("language").String()) }
+		vp.SetLanguage(wanted...)
+	} else {
+		vp.SetLanguage(setting)
+	}
+	js.Global().Get("localStorage").Call("setItem", lang_storage, setting)
+	js.Global().Get("document").Call("getElementById", "language").Set("value", setting)
+	GUI_translate_page()
+}
+
+func load_language() {
+	saved := js.Global().Get("localStorage").Call("getItem", lang_storage)
+	if saved.IsNull() {
+		set_language("auto")
 	} else {
-		if string(rs) == "Enter" { c = '\r' }
+		set_language(saved.String())
+	}
+}
+
+// Translate the text in the page. Elements with fixed text have the English in a data-msg attribute:
+//	<span data-msg="Score:">Score:</span>
+// and the rest is redrawn.
+
+func GUI_translate_page() {
+	document := js.Global().Get("document")
+	document.Get("documentElement").Set("lang", vp.Language())
+
+	elements := document.Call("querySelectorAll", "[data-msg]")
+	for i := 0; i < elements.Length(); i++ {
+		e := elements.Index(i)
+		e.Set("textContent", vp.Tr(e.Call("getAttribute", "data-msg").String()))
 	}
 
-	key_action(byte(c))
+	GUI_update_gamename(vp.GameName(vp.CurrentGame()))
+	GUI_update_button()
+	GUI_update_paytable(vp.NOTHING)
+	GUI_update_bindings()
+	set_mute(muted)
+	set_score(shown_score)
+	GUI_update_message(vp.StateMessage())
+	for i := 0; i < vp.CARDS; i++ { GUI_update_card_label(i) }
+}
+
+// Callback for the language menu in the Settings panel: language("de")
+
+func choose_language(this js.Value, args []js.Value) interface{} {
+	js.Global().Get("document").Get("activeElement").Call("blur")
+	if len(args) < 1 || drawing() { return nil }
+	set_language(args[0].String())
+	return nil
+}
+
+// Text mode commands for the Developer Tools console (see videopoker/command.go):
+//	vp.cmd("deal")
+//	vp.cmd("hold 1 3 5")
+// The cards are shown without animation, so a script can send one command after another.
+
+func console_command(this js.Value, args []js.Value) interface{} {
+	if len(args) < 1 { return nil }
+	if busy() {
+		fmt.Printf("Wait for the cards to be dealt\n")
+		return nil
+	}
+	if remote {
+		fmt.Printf("vp.cmd() doesn't work in remote mode, since the game is played on the server\n")
+		return nil
+	}
 
+	saved := anim_delay
+	anim_delay = 0
+	vp.Command(args[0].String())
+	anim_delay = saved
 	return nil
 }
 
+// The View (see videopoker/view.go) that the engine uses to change the page.
+// Each method is done by one of the GUI_ functions above.
+
+type dom_view struct{}
+
+func (dom_view) Message(msg string)		{ GUI_update_message(msg) }
+func (dom_view) HandName(name string)		{ GUI_update_handname(name) }
+func (dom_view) Hand(hand [vp.CARDS]vp.Card)	{ GUI_update_hand() }
+func (dom_view) Hold(n int, held bool)		{ GUI_update_hold(n) }
+func (dom_view) FaceDown(n int)			{ GUI_face_down(n) }
+func (dom_view) Score(score int)		{ GUI_update_score(score) }
+func (dom_view) Button(state int) {
+	GUI_update_button()
+	embed_state(state)
+	save_limits()	// the chips and time counted for the limits (see limits.go)
+}
+func (dom_view) Paytable(win int)		{ GUI_update_paytable(win) }
+func (dom_view) Announce(msg string)		{ GUI_announce(msg) }
+func (dom_view) Sound(name string)		{ GUI_sound(name) }
+func (dom_view) Win(handtype int) {
+	GUI_win_sound(handtype)
+	embed_result(handtype)
+}
+
+func (dom_view) Limit(msg string)		{ GUI_limit(msg) }
+
+func (dom_view) Game(g int) {
+	GUI_update_gamename(vp.GameName(g))
+	GUI_update_gamemenu()
+}
+
+func (dom_view) Summary(lines []string) {
+	if len(lines) == 0 {
+		GUI_hide_summary()
+	} else {
+		GUI_show_summary(lines...)
+	}
+}
+
 // connect events (from the JavaScript engine) to the callback functions in this file
 
 func register_callbacks() {
 
 	// Event handler for keyboard events, which are set up with
-	//	<body onkeypress="key(event)">
+	//	<body onkeydown="key(event)">
 	// in index.html
 
 	// In the earlier release, for Go 1.11, there was a js.NewEventCallback()
@@ -219,10 +665,38 @@ func register_callbacks() {
 	// FuncOf() returns a *synchronous* callback, so we can call preventDefault() and
 	// stopPropagation() directly in the event handler. (See the key() function.)
 	
-	// For Go 1.12, the 'onkeypress' event handler is added like any other:
+	// For Go 1.12, the 'onkeydown' event handler is added like any other:
 
 	js.Global().Set("key", js.FuncOf(key))
 
+	// for the key bindings in the Settings panel
+	register_key_callbacks()
+
+	// for the high contrast button in the Settings panel
+	js.Global().Set("highcontrast", js.FuncOf(toggle_contrast))
+
+	// for the animation speed menu in the Settings panel
+	js.Global().Set("animspeed", js.FuncOf(choose_speed))
+
+	// for the sound settings in the Settings panel
+	register_audio_callbacks()
+
+	// for the limits in the Settings panel, and the dialog when one is reached
+	register_limit_callbacks()
+
+	// for the Autoplay panel
+	register_autoplay_callbacks()
+
+	// for the card theme menu in the Settings panel
+	js.Global().Set("cardtheme", js.FuncOf(choose_theme))
+
+	// for the language menu in the Settings panel
+	js.Global().Set("language", js.FuncOf(choose_language))
+
+	// for typing commands in the Developer Tools console, and for pages
+	// the game is embedded in (see embed.go)
+	register_embed_callbacks()
+
 	// clicks on card images, left to right
 	js.Global().Set("hold1", js.FuncOf(hold1))
 	js.Global().Set("hold2", js.FuncOf(hold2))
@@ -233,27 +707,51 @@ func register_callbacks() {
 	// for clicks on the Deal/Draw button
 	js.Global().Set("deal_or_draw", js.FuncOf(deal_or_draw))
 
-	// click on Change Game button
-	js.Global().Set("jacks_or_better", js.FuncOf(jacks_or_better))
+	// clicks on the buttons in the Choose Game menu
+	js.Global().Set("changegame", js.FuncOf(choose_game))
 }
 
 func main() {
 	register_callbacks()
+	load_bindings()
+	load_contrast()
+	load_animation_speed()
+	load_audio_settings()
+	load_theme()
 
 	// startup message for the Developer Tools console
 	fmt.Printf("WebAssembly program started\n")
+	fmt.Printf("Type vp.cmd(\"help\") for the text mode commands\n")
 
 	// Start videopoker
 
 	// Up to here, the HTML displays a "The game is loading. Please wait." message with the Deal/Draw button hidden.
 	// Now that the game is running, change those.
 	GUI_button_visible()	// make Deal button visible
-	GUI_update_message(msg_deal)
+	GUI_update_message(vp.StateMessage())
+	GUI_update_gamemenu()
+	GUI_update_paytable(vp.NOTHING)
+
+	vp.Start(dom_view{})	// Initialize and start the game. See videopoker/videopoker.go
+
+	// Now that the hand has been set up, the page can be translated
+	load_language()
+
+	// The limits and what has been counted so far, so reloading the page doesn't reset them
+	load_limits()
+
+	// In remote mode, the game is played on the server (see remote.go)
+	check_remote()
+	if remote { remote_start() }
+
+	// Autoplay is only for the game played in the page (see autoplay.go)
+	if remote || watch_id != "" { autoplay_hide() }
 
-	videopoker()	// Initialize and start the game. See videopoker-web.go
+	// Spectators watch a game on the server (see live.go)
+	if watch_id != "" { watch_start() }
 
 	// Game play is event driven.
-	// The event handlers in this file call key_action() in videopoker-web.go
+	// The event handlers in this file call the functions in videopoker/api.go
 	//
 	// To keep the app running, we need to keep main() from exiting.
 	// An empty select statement is a simple way to block this goroutine.
@@ -0,0 +1,220 @@
+//go:build !js
+
+// Keeping an eye on the web server
+//
+// Each request is logged with log/slog, as text or JSON (see -log in webserver.go),
+// and counted for /metrics, which has what the server has done so far in the
+// Prometheus text format:
+//
+//	GET /metrics	requests, bytes sent, how long requests took, and games played
+//	GET /healthz	"ok", while the server is running
+//
+// Requests are counted by the route they matched, like "/api/session/{id}/{action}",
+// not by their URL, so there aren't a lot of different ones.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"net/http"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// A counter, with the values of its labels, like `route="/metrics",code="200"`
+
+type metric_key struct {
+	name string
+	labels string
+}
+
+type histogram struct {
+	counts []int64	// for each of latency_buckets
+	sum float64
+	count int64
+}
+
+// Upper bounds for the times requests take, in seconds
+
+var latency_buckets []float64 = []float64 { 0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5 }
+
+var metrics_lock sync.Mutex
+var counters map[metric_key]float64 = map[metric_key]float64{}
+var latencies map[string]*histogram = map[string]*histogram{}	// by route
+
+var metric_help map[string]string = map[string]string {
+	"http_requests_total":          "HTTP requests, by route, method and status code.",
+	"http_response_bytes_total":    "Bytes sent in the bodies of responses, by route.",
+	"http_request_duration_seconds": "How long requests took, by route.",
+	"game_sessions_started_total":  "Game sessions started with the API.",
+	"game_sessions_ended_total":    "Game sessions that ended, by variant.",
+	"game_hands_total":             "Hands played with the API, by variant.",
+	"game_sessions_active":         "Game sessions the server is keeping.",
+	"game_spectators_total":        "Spectators who started watching a session.",
+	"leaderboard_records":          "Sessions on the leaderboard.",
+	"go_goroutines":                "Goroutines that exist.",
+}
+
+// A label value, with the characters Prometheus needs escaped
+
+func label(name, value string) string {
+	value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+	return name + `="` + value + `"`
+}
+
+func count(name, labels string, n float64) {
+	metrics_lock.Lock()
+	counters[metric_key{ name, labels }] += n
+	metrics_lock.Unlock()
+}
+
+func observe_latency(route string, seconds float64) {
+	metrics_lock.Lock()
+	defer metrics_lock.Unlock()
+	h, ok := latencies[route]
+	if !ok {
+		h = &histogram{ counts: make([]int64, len(latency_buckets)) }
+		latencies[route] = h
+	}
+	for i, b := range latency_buckets {
+		if seconds <= b { h.counts[i]++ }
+	}
+	h.sum += seconds
+	h.count++
+}
+
+// A ResponseWriter that remembers the status and counts the bytes
+
+type logged_writer struct {
+	http.ResponseWriter
+	status int
+	bytes int64
+}
+
+func (w *logged_writer) WriteHeader(status int) {
+	if w.status == 0 { w.status = status }
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *logged_writer) Write(b []byte) (int, error) {
+	if w.status == 0 { w.status = http.StatusOK }
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// So http.ResponseController can find Flush and Hijack
+
+func (w *logged_writer) Unwrap() http.ResponseWriter { return w.ResponseWriter }
+
+// Log and count each request that mux handles
+
+func observe(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		lw := &logged_writer{ ResponseWriter: w }
+		mux.ServeHTTP(lw, r)
+		took := time.Since(start)
+		if lw.status == 0 { lw.status = http.StatusOK }
+
+		// the mux puts the pattern that matched in the request, like "POST /api/session"
+		route := r.Pattern
+		if _, path, ok := strings.Cut(route, " "); ok { route = path }
+		if route == "" { route = "none" }
+
+		count("http_requests_total", label("route", route) + "," + label("method", r.Method) + "," +
+			label("code", strconv.Itoa(lw.status)), 1)
+		count("http_response_bytes_total", label("route", route), float64(lw.bytes))
+		observe_latency(route, took.Seconds())
+
+		slog.Info("request", "method", r.Method, "path", r.URL.Path, "route", route, "status", lw.status,
+			"bytes", lw.bytes, "duration", took, "remote", r.RemoteAddr)
+	})
+}
+
+func register_observe(mux *http.ServeMux) {
+	mux.HandleFunc("GET /metrics", serve_metrics)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		w.Header().Set("Cache-Control", "no-store")
+		io.WriteString(w, "ok\n")
+	})
+}
+
+func number_string(v float64) string {
+	if math.IsInf(v, 1) { return "+Inf" }
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// GET /metrics
+
+func serve_metrics(w http.ResponseWriter, r *http.Request) {
+	type line struct{ name, labels, value string }
+	var lines []line
+
+	/* gauges, which are found out now */
+	sessions_lock.Lock()
+	active := len(sessions)
+	sessions_lock.Unlock()
+	lines = append(lines, line{ "game_sessions_active", "", strconv.Itoa(active) })
+	if scores != nil {
+		scores.lock.Lock()
+		lines = append(lines, line{ "leaderboard_records", "", strconv.Itoa(len(scores.records)) })
+		scores.lock.Unlock()
+	}
+	lines = append(lines, line{ "go_goroutines", "", strconv.Itoa(runtime.NumGoroutine()) })
+
+	metrics_lock.Lock()
+	for k, v := range counters { lines = append(lines, line{ k.name, k.labels, number_string(v) }) }
+	sort.Slice(lines, func(i, j int) bool { return lines[i].labels < lines[j].labels })
+	var routes []string
+	for route := range latencies { routes = append(routes, route) }
+	sort.Strings(routes)
+	for _, route := range routes {
+		h := latencies[route]
+		name := "http_request_duration_seconds"
+		for i, b := range latency_buckets {
+			lines = append(lines, line{ name + "_bucket", label("route", route) + "," + label("le", number_string(b)), strconv.FormatInt(h.counts[i], 10) })
+		}
+		lines = append(lines, line{ name + "_bucket", label("route", route) + `,le="+Inf"`, strconv.FormatInt(h.count, 10) })
+		lines = append(lines, line{ name + "_sum", label("route", route), number_string(h.sum) })
+		lines = append(lines, line{ name + "_count", label("route", route), strconv.FormatInt(h.count, 10) })
+	}
+	metrics_lock.Unlock()
+
+	// the lines of each metric have to be together, after its HELP and TYPE
+	family := func(name string) string {
+		for _, suffix := range []string{ "_bucket", "_sum", "_count" } {
+			if f := strings.TrimSuffix(name, suffix); f != name && strings.HasSuffix(f, "_seconds") { return f }
+		}
+		return name
+	}
+	sort.SliceStable(lines, func(i, j int) bool { return family(lines[i].name) < family(lines[j].name) })
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	last := ""
+	for _, l := range lines {
+		if f := family(l.name); f != last {
+			kind := "counter"
+			switch {
+				case strings.HasSuffix(f, "_seconds"): kind = "histogram"
+				case !strings.HasSuffix(f, "_total"): kind = "gauge"
+			}
+			fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f, metric_help[f], f, kind)
+			last = f
+		}
+		if l.labels == "" {
+			fmt.Fprintf(w, "%s %s\n", l.name, l.value)
+		} else {
+			fmt.Fprintf(w, "%s{%s} %s\n", l.name, l.labels, l.value)
+		}
+	}
+}
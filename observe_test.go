@@ -0,0 +1,54 @@
+//go:build !js
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	mux := http.NewServeMux()
+	register_observe(mux)
+	mux.HandleFunc("GET /teapot/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		io.WriteString(w, "short and stout")
+	})
+	server := httptest.NewServer(observe(mux))
+	defer server.Close()
+
+	get := func(path string) (int, string) {
+		r, err := http.Get(server.URL + path)
+		if err != nil { t.Fatal(err) }
+		defer r.Body.Close()
+		b, _ := io.ReadAll(r.Body)
+		return r.StatusCode, string(b)
+	}
+
+	if status, body := get("/healthz"); status != http.StatusOK || body != "ok\n" {
+		t.Errorf("healthz: %d %q", status, body)
+	}
+	get("/teapot/1")
+	get("/teapot/2")
+
+	_, metrics := get("/metrics")
+	for _, want := range []string{
+		"# TYPE http_requests_total counter\n",
+		`http_requests_total{route="/teapot/{id}",method="GET",code="418"} 2` + "\n",
+		`http_response_bytes_total{route="/teapot/{id}"} 30` + "\n",
+		"# TYPE http_request_duration_seconds histogram\n",
+		`http_request_duration_seconds_bucket{route="/teapot/{id}",le="+Inf"} 2` + "\n",
+		`http_request_duration_seconds_count{route="/teapot/{id}"} 2` + "\n",
+		"# TYPE game_sessions_active gauge\n",
+	} {
+		if !strings.Contains(metrics, want) { t.Errorf("no %q in the metrics:\n%s", want, metrics) }
+	}
+	if n := strings.Count(metrics, "# TYPE http_request_duration_seconds "); n != 1 {
+		t.Errorf("the histogram is in %d parts:\n%s", n, metrics)
+	}
+
+	if got := label("path", `a"b\c`); got != `path="a\"b\\c"` { t.Errorf("label: %s", got) }
+}
@@ -0,0 +1,251 @@
+//go:build js && wasm
+
+// Remote mode: the game is played on the server, and the page shows it
+//
+// When the page is opened with ?remote in the URL, like http://localhost:8080/?remote
+// the server deals the cards and keeps the score (see apiserver.go), so the score can
+// be trusted. The engine in the page is only used to show the game: each answer from
+// the server has the state of the game, which is put into the engine with vp.Load(),
+// and the calls the server's engine made to its View, which are made again on the
+// page's View, so the game looks and sounds the same as when it's played in the page.
+//
+// Holding cards is done in the page, and the holds are sent with the draw.
+// When the session ends, the server puts it on its leaderboard, under the name
+// given with ?name= in the URL, and the summary shows where it ranked.
+// With ?tournament=<id> instead, the page enters that tournament (see tournament.go).
+// Others can watch the session, with the link the page shows (see live.go).
+// The limits (see limits.go) are sent when the session starts, and checked by the server.
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall/js"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+var remote bool		// true in remote mode
+var remote_id string	// the server's id for the session
+var remote_waiting bool	// true while waiting for the server
+var remote_name string	// the player's name for the leaderboard, from ?name= in the URL
+var remote_tournament string	// the tournament being entered, from ?tournament= in the URL
+
+type remote_response struct {
+	ID string		`json:"id"`
+	State vp.Snapshot	`json:"state"`
+	Calls []vp.Call		`json:"calls"`
+	Error string		`json:"error"`
+	Watch string		`json:"watch"`	// for spectators, when the session starts (see live.go)
+	Limits *vp.LimitState	`json:"limits"`	// the limits and the counts so far (see limits.go)
+}
+
+func check_remote() {
+	params := js.Global().Get("URLSearchParams").New(js.Global().Get("location").Get("search"))
+	remote = params.Call("has", "remote").Bool()
+	if name := params.Call("get", "name"); !name.IsNull() { remote_name = name.String() }
+	if t := params.Call("get", "tournament"); !t.IsNull() {
+		remote = true
+		remote_tournament = t.String()
+	}
+	if w := params.Call("get", "watch"); !w.IsNull() && !remote { watch_id = w.String() }
+}
+
+// Wait for a JavaScript Promise. This has to be done in a goroutine,
+// not in a callback from JavaScript.
+
+func await(promise js.Value) (js.Value, error) {
+	var result js.Value
+	var err error
+
+	done := make(chan bool)
+	then := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		result = args[0]
+		done <- true
+		return nil
+	})
+	catch := js.FuncOf(func(this js.Value, args []js.Value) interface{} {
+		err = fmt.Errorf("%s", args[0].Call("toString").String())
+		done <- true
+		return nil
+	})
+	defer then.Release()
+	defer catch.Release()
+
+	promise.Call("then", then, catch)
+	<-done
+	return result, err
+}
+
+// POST a request to the server, and read the answer
+
+func remote_post(address string, body map[string]interface{}) (remote_response, error) {
+	var resp remote_response
+
+	body["lang"] = vp.Language()
+	b, err := json.Marshal(body)
+	if err != nil { return resp, err }
+
+	options := map[string]interface{} {
+		"method": "POST",
+		"headers": map[string]interface{} { "Content-Type": "application/json" },
+		"body": string(b),
+	}
+	r, err := await(js.Global().Call("fetch", address, options))
+	if err != nil { return resp, err }
+	text, err := await(r.Call("text"))
+	if err != nil { return resp, err }
+
+	err = json.NewDecoder(bytes.NewReader([]byte(text.String()))).Decode(&resp)
+	if err == nil && resp.Error == "" && !r.Get("ok").Bool() {
+		err = fmt.Errorf("%s", r.Get("statusText").String())
+	}
+	return resp, err
+}
+
+// Send an action to the server, and show what happened.
+// Input is ignored until the answer comes back. (See busy() in main.go.)
+
+func remote_do(action string, body map[string]interface{}) {
+	if body == nil { body = map[string]interface{}{} }
+	address := "api/session/" + remote_id + "/" + action
+	switch {
+		case action == "" && remote_tournament != "": address = "api/tournament/" + url.PathEscape(remote_tournament) + "/enter"
+		case action == "": address = "api/session"	// a new session
+	}
+
+	remote_waiting = true
+	go func() {
+		resp, err := remote_post(address, body)
+		if err != nil {
+			fmt.Printf("Remote mode: %v\n", err)
+			remote_waiting = false
+			GUI_update_message(vp.Tr("Can't reach the server"))
+			return
+		}
+		if resp.ID != "" { remote_id = resp.ID }
+		if resp.Watch != "" { live_start(resp.Watch) }
+		remote_waiting = false
+		if resp.Limits != nil {
+			vp.RestoreLimits(*resp.Limits)
+			save_limits()
+			if action == "limits" { show_limits() }
+		}
+		remote_show(resp)
+		if resp.Error != "" { GUI_update_message(resp.Error) }
+	}()
+}
+
+// The View for showing the server's calls. The state changes when the
+// Deal/Draw button does, so the page sees the same states in between as when
+// it plays by itself. (The score only counts up after a draw, for example.)
+
+type remote_view struct {
+	dom_view
+	snap *vp.Snapshot
+}
+
+func (v remote_view) Button(state int) {
+	v.snap.Phase = vp.Phase(state)
+	vp.Load(*v.snap)
+	v.dom_view.Button(state)
+}
+
+func remote_show(resp remote_response) {
+	snap := resp.State
+	final := snap.Phase
+
+	snap.Phase = vp.Phase(vp.State())
+	if err := vp.Load(snap); err != nil {
+		fmt.Printf("Remote mode: %v\n", err)
+		return
+	}
+	script := vp.Script{ Calls: resp.Calls }
+	script.Play(remote_view{ snap: &snap })
+
+	snap.Phase = final
+	vp.Load(snap)
+}
+
+// Start a session on the server
+
+func remote_start() {
+	remote_do("", map[string]interface{} { "variant": strconv.Itoa(vp.CurrentGame()), "name": remote_name, "limits": vp.GetLimits() })
+}
+
+// What the player does. These play the game in the page, or on the server in remote mode.
+
+func play_deal_or_draw() {
+	if !remote {
+		vp.DealOrDraw()
+		return
+	}
+	if vp.LimitStopped() { return }		// until the player clicks OK
+	switch vp.State() {
+		case vp.Deal: remote_do("deal", nil)
+		case vp.Draw: remote_do("draw", map[string]interface{} { "holds": vp.GetState().Holds })
+		case vp.Over: remote_do("new", nil)
+	}
+}
+
+func play_bet(m int) {
+	if !remote {
+		vp.Bet(m)
+		return
+	}
+	if vp.State() != vp.Deal || vp.LimitStopped() || m < 1 || m > 5 { return }
+	remote_do("bet", map[string]interface{} { "bet": m })
+}
+
+func play_game(g int) {
+	if !remote {
+		vp.ChangeGame(g)
+		return
+	}
+	remote_do("game", map[string]interface{} { "variant": strconv.Itoa(g) })
+}
+
+func play_quit() {
+	if !remote {
+		vp.Quit()
+		return
+	}
+	if vp.State() == vp.Over { return }
+	remote_do("quit", nil)
+}
+
+// A command from the embedding API (see embed.go), in remote mode.
+// The answer comes later, with the events.
+
+func remote_command(method string, args []string) error {
+	state := vp.State()
+	switch method {
+		case "deal", "draw", "newSession":
+			want := map[string]int { "deal": vp.Deal, "draw": vp.Draw, "newSession": vp.Over }[method]
+			if state != want { return fmt.Errorf("can't %s now: %s", method, vp.StateMessage()) }
+			play_deal_or_draw()
+		case "hold":
+			return vp.Command("hold " + strings.Join(args, " "))
+		case "bet":
+			if len(args) != 1 { return fmt.Errorf("usage: bet 1-5") }
+			m, err := strconv.Atoi(args[0])
+			if err != nil || m < 1 || m > 5 { return fmt.Errorf("bet must be 1 to 5") }
+			if state != vp.Deal { return fmt.Errorf("the bet can only be changed before the hand is dealt") }
+			play_bet(m)
+		case "game":
+			g, err := vp.FindGame(strings.Join(args, " "))
+			if err != nil { return err }
+			play_game(g)
+		case "quit":
+			if state == vp.Over { return fmt.Errorf("the session has already ended") }
+			play_quit()
+		default:
+			return fmt.Errorf("unknown method %q", method)
+	}
+	return nil
+}
@@ -0,0 +1,354 @@
+//go:build !js
+
+// Watching a game on the server while it's played
+//
+// Each session on the server (see apiserver.go) has a second id for watching it,
+// which the player is given as "watch" when the session starts. The page shows it
+// as a link like http://localhost:8080/?watch=<watch id>, which shows the game
+// without letting anyone play it. Tournament sessions can't be watched, so nobody
+// can learn the tournament's cards that way. Spectators open a WebSocket (see websocket.go) to
+//
+//	GET /api/watch/{wid}?name=Kim
+//
+// and are sent a message when they connect, and each time the player does something:
+//
+//	{ "type": "game", "state": { ... }, "calls": [ ... ], "best": [true, ...], "bestValue": 1.54, ... }
+//	{ "type": "holds", "holds": [true, false, false, false, false] }	the player changed the holds
+//	{ "type": "comment", "name": "Kim", "text": "Keep the pair!" }
+//
+// "state" and "calls" are the same as in the answers to the player. While the cards
+// can be drawn, "best" is the hold that pays the most on average (see BestHold in
+// videopoker/strategy.go), and "bestValue" is what it pays for each chip bet, so a
+// coach can help the player decide. After the draw, "dealt" is the hand as it was
+// dealt, with "best" and "bestValue" again, and "heldValue" is what the player's
+// hold paid, to compare. The best hold is only sent to the spectators: the watch id
+// is a different token from the session id, so the player's page, which uses the
+// session id, never gets it, and spectators can't play.
+// Spectators send comments like { "comment": "Keep the pair!" }, one a second at most.
+//
+// The player's page opens a WebSocket to
+//
+//	GET /api/session/{id}/live
+//
+// which is sent the comments, and { "type": "spectators", "spectators": 2 } when
+// spectators come and go. It sends { "holds": [...] } when the player changes them.
+
+package main
+
+import (
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+)
+
+const max_spectators = 20
+const max_comment = 100		// characters
+const comment_interval = time.Second
+
+type watch_message struct {
+	Type string		`json:"type"`
+	State *vp.Snapshot	`json:"state,omitempty"`
+	Calls []vp.Call		`json:"calls,omitempty"`
+	Dealt []string		`json:"dealt,omitempty"`
+	Best []bool		`json:"best,omitempty"`
+	BestValue float64	`json:"bestValue,omitempty"`
+	HeldValue *float64	`json:"heldValue,omitempty"`
+	Holds []bool		`json:"holds,omitempty"`
+	Name string		`json:"name,omitempty"`
+	Text string		`json:"text,omitempty"`
+	Spectators *int		`json:"spectators,omitempty"`
+}
+
+// What is sent on the WebSockets: { "comment": "..." } or { "holds": [...] }
+
+type watch_request struct {
+	Comment string	`json:"comment"`
+	Holds []bool	`json:"holds"`
+}
+
+// The WebSockets of a session
+
+type watchers struct {
+	id string
+	lock sync.Mutex
+	spectators map[*ws_conn]bool
+	players map[*ws_conn]bool
+	dealt []string		// the hand before the draw, for what the player's hold was worth
+	best []bool		// the best hold for dealt, once it's been found
+	best_value float64
+	last chan bool		// closed when the last update has been sent, so they're sent in order
+}
+
+// The sessions, by their watch ids. It's locked with sessions_lock.
+
+var watched map[string]*api_session = map[string]*api_session{}
+
+// The WebSockets that are open, so they can be closed when the server stops
+
+var live_conns map[*ws_conn]bool = map[*ws_conn]bool{}
+var live_lock sync.Mutex
+
+func register_spectate(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/watch/{wid}", api_watch)
+	mux.HandleFunc("GET /api/session/{id}/live", api_live)
+}
+
+func new_watchers() *watchers {
+	return &watchers{ id: new_id(), spectators: map[*ws_conn]bool{}, players: map[*ws_conn]bool{} }
+}
+
+func open_live(w http.ResponseWriter, r *http.Request) *ws_conn {
+	c, err := ws_upgrade(w, r)
+	if err != nil { return nil }
+	live_lock.Lock()
+	live_conns[c] = true
+	live_lock.Unlock()
+	return c
+}
+
+func close_live_conn(c *ws_conn) {
+	c.close()
+	live_lock.Lock()
+	delete(live_conns, c)
+	live_lock.Unlock()
+}
+
+// Close all of the WebSockets, when the server stops (see webserver.go).
+// http.Server.Shutdown() doesn't, since they aren't HTTP any more.
+
+func close_live() {
+	live_lock.Lock()
+	defer live_lock.Unlock()
+	for c := range live_conns {
+		c.write_frame(ws_close, []byte{ 0x03, 0xe9 })	// 1001, going away
+		c.close()
+	}
+	live_conns = map[*ws_conn]bool{}
+}
+
+// Send a message to some of the WebSockets. The ones that can't be sent to are closed.
+
+func (wt *watchers) send(to map[*ws_conn]bool, msg watch_message) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		slog.Error("can't send to the spectators", "error", err)
+		return
+	}
+	wt.lock.Lock()
+	conns := make([]*ws_conn, 0, len(to))
+	for c := range to { conns = append(conns, c) }
+	wt.lock.Unlock()
+
+	for _, c := range conns {
+		if c.send(b) != nil { close_live_conn(c) }
+	}
+}
+
+// Tell the player how many are watching
+
+func (wt *watchers) count() {
+	wt.lock.Lock()
+	n := len(wt.spectators)
+	wt.lock.Unlock()
+	wt.send(wt.players, watch_message{ Type: "spectators", Spectators: &n })
+}
+
+func (wt *watchers) close_all() {
+	wt.lock.Lock()
+	conns := []*ws_conn{}
+	for c := range wt.spectators { conns = append(conns, c) }
+	for c := range wt.players { conns = append(conns, c) }
+	wt.lock.Unlock()
+	for _, c := range conns { close_live_conn(c) }
+}
+
+// The cards in a snapshot, and the game
+
+func snapshot_hand(state *vp.Snapshot) ([vp.CARDS]vp.Card, int, bool) {
+	var h [vp.CARDS]vp.Card
+	g, err := vp.FindGame(state.Variant)
+	if err != nil || len(state.Hand) != vp.CARDS { return h, 0, false }
+	for i, s := range state.Hand {
+		c, ok := vp.ParseCard(s)
+		if !ok || c.Blank() { return h, 0, false }
+		h[i] = c
+	}
+	return h, g, true
+}
+
+// The best hold for the cards in a snapshot, or nil
+
+func best_hold(state *vp.Snapshot) ([]bool, float64) {
+	h, g, ok := snapshot_hand(state)
+	if !ok { return nil, 0 }
+	best, value := vp.BestHold(h, g)
+	return best[:], value
+}
+
+// Show the spectators what the player did, which is in resp, in another goroutine,
+// since finding the best hold takes a while and the player shouldn't wait for it.
+// For a draw, holds are the cards that were held.
+
+func (wt *watchers) update(resp api_response, holds []bool) {
+	holds = append([]bool(nil), holds...)
+	done := make(chan bool)
+	wt.lock.Lock()
+	last := wt.last
+	wt.last = done
+	wt.lock.Unlock()
+
+	go func() {
+		if last != nil { <-last }
+		wt.game(resp, holds)
+		close(done)
+	}()
+}
+
+func (wt *watchers) game(resp api_response, holds []bool) {
+	state := resp.State
+	msg := watch_message{ Type: "game", State: &state, Calls: resp.Calls }
+
+	wt.lock.Lock()
+	dealt := &vp.Snapshot{ Variant: state.Variant, Hand: wt.dealt }
+	best, best_value := wt.best, wt.best_value
+	if state.Phase == "draw" { wt.dealt = state.Hand } else { wt.dealt = nil }
+	wt.best, wt.best_value = nil, 0
+	watching := len(wt.spectators) > 0
+	wt.lock.Unlock()
+	if !watching { return }
+
+	switch {
+		case state.Phase == "draw":
+			msg.Best, msg.BestValue = best_hold(&state)
+			wt.lock.Lock()
+			wt.best, wt.best_value = msg.Best, msg.BestValue
+			wt.lock.Unlock()
+		case len(holds) == vp.CARDS:
+			h, g, ok := snapshot_hand(dealt)
+			if !ok { break }
+			if best == nil { best, best_value = best_hold(dealt) }
+			var held [vp.CARDS]bool
+			copy(held[:], holds)
+			value := vp.HoldValue(h, g, held)
+			msg.Dealt, msg.Best, msg.BestValue, msg.HeldValue = dealt.Hand, best, best_value, &value
+	}
+	wt.send(wt.spectators, msg)
+}
+
+// The calls that show the game as it is, for a spectator who has just come
+
+func replay(s *api_session) (vp.Snapshot, []vp.Call) {
+	var state vp.Snapshot
+	script := &vp.Script{}
+	s.game.Do(vp.NoView{}, func() {
+		state = vp.GetState()
+		script.Game(vp.CurrentGame())
+		script.Paytable(vp.NOTHING)
+		script.Hand(vp.Hand())
+		for i := 0; i < vp.CARDS; i++ { script.Hold(i, vp.Held(i)) }
+		script.Score(vp.Score())
+		script.Button(vp.State())
+		script.Message(vp.StateMessage())
+	})
+	return state, script.Calls
+}
+
+// A comment, cut to max_comment characters, without control characters
+
+func clean_comment(text string) string {
+	text = strings.Map(func(r rune) rune {
+		if r < ' ' || r == 0x7f { return ' ' }
+		return r
+	}, strings.TrimSpace(text))
+	if utf8.RuneCountInString(text) > max_comment { text = string([]rune(text)[:max_comment]) }
+	return strings.TrimSpace(text)
+}
+
+// GET /api/watch/{wid}
+
+func api_watch(w http.ResponseWriter, r *http.Request) {
+	sessions_lock.Lock()
+	s := watched[r.PathValue("wid")]
+	sessions_lock.Unlock()
+	if s == nil {
+		api_error(w, http.StatusNotFound, "no such session")
+		return
+	}
+	wt := s.watch
+	wt.lock.Lock()
+	full := len(wt.spectators) >= max_spectators
+	wt.lock.Unlock()
+	if full {
+		api_error(w, http.StatusServiceUnavailable, "too many spectators")
+		return
+	}
+
+	c := open_live(w, r)
+	if c == nil { return }
+	name := player_name(r.URL.Query().Get("name"))
+
+	state, calls := replay(s)
+	hello := watch_message{ Type: "game", State: &state, Calls: calls }
+	if state.Phase == "draw" { hello.Best, hello.BestValue = best_hold(&state) }
+	wt.lock.Lock()
+	wt.spectators[c] = true
+	wt.lock.Unlock()
+	wt.count()
+	wt.send(map[*ws_conn]bool{ c: true }, hello)
+	count("game_spectators_total", "", 1)
+
+	var last time.Time
+	for {
+		b, err := c.receive()
+		if err != nil { break }
+		var req watch_request
+		if json.Unmarshal(b, &req) != nil { continue }
+		text := clean_comment(req.Comment)
+		if text == "" || time.Since(last) < comment_interval { continue }
+		last = time.Now()
+		comment := watch_message{ Type: "comment", Name: name, Text: text }
+		wt.send(wt.players, comment)
+		wt.send(wt.spectators, comment)
+	}
+
+	wt.lock.Lock()
+	delete(wt.spectators, c)
+	wt.lock.Unlock()
+	close_live_conn(c)
+	wt.count()
+}
+
+// GET /api/session/{id}/live
+
+func api_live(w http.ResponseWriter, r *http.Request) {
+	_, s := find_session(w, r)
+	if s == nil { return }
+	wt := s.watch
+
+	c := open_live(w, r)
+	if c == nil { return }
+	wt.lock.Lock()
+	wt.players[c] = true
+	n := len(wt.spectators)
+	wt.lock.Unlock()
+	wt.send(map[*ws_conn]bool{ c: true }, watch_message{ Type: "spectators", Spectators: &n })
+
+	for {
+		b, err := c.receive()
+		if err != nil { break }
+		var req watch_request
+		if json.Unmarshal(b, &req) != nil || len(req.Holds) != vp.CARDS { continue }
+		wt.send(wt.spectators, watch_message{ Type: "holds", Holds: req.Holds })
+	}
+
+	wt.lock.Lock()
+	delete(wt.players, c)
+	wt.lock.Unlock()
+	close_live_conn(c)
+}
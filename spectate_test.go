@@ -0,0 +1,181 @@
+//go:build !js
+
+package main
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+)
+
+// The browser's end of a WebSocket
+
+type ws_client struct {
+	t *testing.T
+	conn net.Conn
+	in *bufio.Reader
+}
+
+func ws_dial(t *testing.T, server *httptest.Server, path string) *ws_client {
+	t.Helper()
+	conn, err := net.Dial("tcp", strings.TrimPrefix(server.URL, "http://"))
+	if err != nil { t.Fatal(err) }
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+	io.WriteString(conn, "GET " + path + " HTTP/1.1\r\nHost: " + strings.TrimPrefix(server.URL, "http://") + "\r\n" +
+		"Connection: Upgrade\r\nUpgrade: websocket\r\nSec-WebSocket-Version: 13\r\n" +
+		"Sec-WebSocket-Key: dGhlIHNhbXBsZSBub25jZQ==\r\n\r\n")
+	in := bufio.NewReader(conn)
+	r, err := http.ReadResponse(in, nil)
+	if err != nil { t.Fatal(err) }
+	if r.StatusCode != http.StatusSwitchingProtocols || r.Header.Get("Sec-WebSocket-Accept") != "s3pPLMBiTxaQ9kYGzzhZRbK+xOo=" {
+		t.Fatalf("%s: %d %v", path, r.StatusCode, r.Header)
+	}
+	return &ws_client{ t, conn, in }
+}
+
+func (c *ws_client) frame(first byte, data string) {
+	header := []byte{ first, 0x80 | byte(len(data)) }
+	if len(data) >= 126 { header = binary.BigEndian.AppendUint16([]byte{ first, 0x80 | 126 }, uint16(len(data))) }
+	mask := []byte{ 1, 2, 3, 4 }
+	b := []byte(data)
+	for i := range b { b[i] ^= mask[i % 4] }
+	c.conn.Write(append(append(header, mask...), b...))
+}
+
+func (c *ws_client) send(msg string) { c.frame(0x80 | ws_text, msg) }
+
+// The next frame from the server
+
+func (c *ws_client) read() (byte, []byte) {
+	c.t.Helper()
+	var h [2]byte
+	if _, err := io.ReadFull(c.in, h[:]); err != nil { c.t.Fatal(err) }
+	n := int(h[1] & 0x7f)
+	if n == 126 {
+		var b [2]byte
+		io.ReadFull(c.in, b[:])
+		n = int(binary.BigEndian.Uint16(b[:]))
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(c.in, data); err != nil { c.t.Fatal(err) }
+	return h[0] & 0x0f, data
+}
+
+func (c *ws_client) receive() watch_message {
+	c.t.Helper()
+	var msg watch_message
+	opcode, data := c.read()
+	if opcode != ws_text { c.t.Fatalf("opcode %d: %q", opcode, data) }
+	if err := json.Unmarshal(data, &msg); err != nil { c.t.Fatal(err) }
+	return msg
+}
+
+func TestWebSocket(t *testing.T) {
+	got := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := ws_upgrade(w, r)
+		if err != nil { return }
+		msg, err := c.receive()
+		if err != nil { msg = []byte(err.Error()) }
+		got <- string(msg)
+		c.send([]byte(strings.Repeat("x", 300)))
+		_, err = c.receive()
+		got <- err.Error()
+	}))
+	defer server.Close()
+
+	/* a message in two frames, with a ping in between */
+	c := ws_dial(t, server, "/")
+	c.frame(ws_text, "hello, ")
+	c.frame(0x80 | ws_ping, "are you there?")
+	c.frame(0x80 | ws_continuation, "world")
+	if opcode, data := c.read(); opcode != ws_pong || string(data) != "are you there?" { t.Errorf("pong: %d %q", opcode, data) }
+	if msg := <-got; msg != "hello, world" { t.Errorf("message: %q", msg) }
+	if opcode, data := c.read(); opcode != ws_text || len(data) != 300 { t.Errorf("long message: %d %d", opcode, len(data)) }
+
+	/* messages that are too long are refused */
+	c.send(strings.Repeat("y", max_ws_message + 1))
+	if msg := <-got; msg != "the message is too long" { t.Errorf("too long: %q", msg) }
+	if opcode, data := c.read(); opcode != ws_close || string(data[2:]) != "the message is too long" { t.Errorf("close: %d %q", opcode, data) }
+
+	/* plain requests, and pages from other sites */
+	r, _ := http.Get(server.URL)
+	if r.StatusCode != http.StatusUpgradeRequired { t.Errorf("not a WebSocket: %d", r.StatusCode) }
+	req, _ := http.NewRequest("GET", server.URL, nil)
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Sec-WebSocket-Version", "13")
+	req.Header.Set("Sec-WebSocket-Key", "dGhlIHNhbXBsZSBub25jZQ==")
+	req.Header.Set("Origin", "https://example.com")
+	r, _ = http.DefaultClient.Do(req)
+	if r.StatusCode != http.StatusForbidden { t.Errorf("another site: %d", r.StatusCode) }
+}
+
+func TestSpectate(t *testing.T) {
+	vp.Console = io.Discard
+	mux := http.NewServeMux()
+	register_api(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	defer close_live()
+
+	_, resp := api_post(t, server, "/api/session", `{"variant":"JacksOrBetter"}`)
+	if resp.Watch == "" || resp.Watch == resp.ID { t.Fatalf("watch id: %+v", resp) }
+	session := "/api/session/" + resp.ID
+
+	/* a spectator is shown the game as it is */
+	spectator := ws_dial(t, server, "/api/watch/" + resp.Watch + "?name=Coach")
+	hello := spectator.receive()
+	if hello.Type != "game" || hello.State.Phase != "deal" || len(hello.Calls) == 0 || hello.Best != nil {
+		t.Errorf("hello: %+v", hello)
+	}
+	player := ws_dial(t, server, session + "/live")
+	if msg := player.receive(); msg.Type != "spectators" || *msg.Spectators != 1 { t.Errorf("spectators: %+v", msg) }
+
+	/* the deal, with the best hold for the spectators, but not for the player */
+	_, resp = api_post(t, server, session + "/deal", ``)
+	msg := spectator.receive()
+	if msg.Type != "game" || msg.State.Phase != "draw" || len(msg.Best) != vp.CARDS || msg.BestValue <= 0 {
+		t.Errorf("deal: %+v", msg)
+	}
+	best := msg.Best
+	dealt := strings.Join(resp.State.Hand, " ")
+	if strings.Join(msg.State.Hand, " ") != dealt { t.Errorf("hands: %v %v", msg.State.Hand, resp.State.Hand) }
+
+	/* the player's holds, and comments */
+	player.send(`{"holds":[true,false,false,false,true]}`)
+	if msg = spectator.receive(); msg.Type != "holds" || !msg.Holds[0] || msg.Holds[1] || !msg.Holds[4] { t.Errorf("holds: %+v", msg) }
+	spectator.send(`{"comment":"  Keep\nthe ` + strings.Repeat("a", 200) + `"}`)
+	msg = player.receive()
+	if msg.Type != "comment" || msg.Name != "Coach" || !strings.HasPrefix(msg.Text, "Keep the aaa") || len(msg.Text) != max_comment {
+		t.Errorf("comment: %+v", msg)
+	}
+	if msg = spectator.receive(); msg.Type != "comment" { t.Errorf("the comment for the spectators: %+v", msg) }
+
+	/* the draw, with the best hold and what the player's was worth */
+	api_post(t, server, session + "/draw", `{"holds":[true,false,false,false,true]}`)
+	msg = spectator.receive()
+	if msg.Type != "game" || msg.State.Phase != "deal" || len(msg.Best) != vp.CARDS || msg.BestValue <= 0 ||
+		msg.HeldValue == nil || *msg.HeldValue > msg.BestValue + 1e-9 || strings.Join(msg.Dealt, " ") != dealt ||
+		fmt.Sprint(msg.Best) != fmt.Sprint(best) {
+		t.Errorf("draw: %+v", msg)
+	}
+
+	/* the spectator leaves */
+	spectator.frame(0x80 | ws_close, "")
+	if opcode, _ := spectator.read(); opcode != ws_close { t.Errorf("close: %d", opcode) }
+	if msg := player.receive(); msg.Type != "spectators" || *msg.Spectators != 0 { t.Errorf("spectators: %+v", msg) }
+
+	r, _ := http.Get(server.URL + "/api/watch/nosuchsession")
+	if r.StatusCode != http.StatusNotFound { t.Errorf("no such session: %d", r.StatusCode) }
+}
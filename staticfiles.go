@@ -0,0 +1,221 @@
+//go:build !js
+
+// Serving the files for the web page
+//
+// http.FileServer guesses the type of a file from the system's MIME types, which
+// don't always have application/wasm, and without it the browser can't compile
+// main.wasm while it's being downloaded. It also sends main.wasm uncompressed,
+// which is several megabytes. So the files are served this way instead:
+//
+//   - The Content-Type comes from the file name, and .wasm is always application/wasm.
+//   - If the browser accepts it and there's a main.wasm.br or main.wasm.gz made
+//     from this main.wasm, that's sent instead, with its Content-Encoding.
+//     "make compress" makes them, and puts main.wasm's hash in main.wasm.sum.
+//     If the hashes don't match, they were made from an older main.wasm. (The
+//     modification times can't tell, since the files built into the server have none.)
+//   - Each file has an ETag, a hash of what's in it, so the browser can check
+//     whether its copy is still good without downloading it again.
+//   - In index.html, the files it loads get their hash added to their URLs,
+//     like main.wasm?v=1a2b3c4d5e6f7a8b, and a URL with the right hash can be
+//     kept by the browser for a year, since it changes when the file does.
+//     Everything else has to be checked each time it's used.
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+var content_types map[string]string = map[string]string {
+	".wasm": "application/wasm",
+	".js":   "text/javascript; charset=utf-8",
+	".css":  "text/css; charset=utf-8",
+	".html": "text/html; charset=utf-8",
+	".svg":  "image/svg+xml",
+	".png":  "image/png",
+	".ico":  "image/x-icon",
+	".json": "application/json",
+	".mp3":  "audio/mpeg",
+	".ogg":  "audio/ogg",
+	".wav":  "audio/wav",
+}
+
+// The compressed versions of a file that are looked for, best first
+
+var encodings []struct{ name, ext string } = []struct{ name, ext string } {
+	{ "br", ".br" },
+	{ "gzip", ".gz" },
+}
+
+const cache_forever = "public, max-age=31536000, immutable"
+const cache_check = "no-cache"
+
+type static_files struct {
+	files fs.FS
+	inject string	// put at the end of index.html's <body>, like the script for -dev (see devmode.go)
+	lock sync.Mutex
+	hashes map[string]file_hash
+}
+
+// The hash of a file, and what the file was like when it was hashed
+
+type file_hash struct {
+	size int64
+	modified time.Time
+	hash string
+}
+
+func new_static_files(files fs.FS) *static_files {
+	return &static_files{ files: files, hashes: map[string]file_hash{} }
+}
+
+func content_type(name string) string {
+	ext := path.Ext(name)
+	if t, ok := content_types[ext]; ok { return t }
+	if t := mime.TypeByExtension(ext); t != "" { return t }
+	return "application/octet-stream"
+}
+
+// The hash of a file, which is worked out again when it changes
+
+func (sf *static_files) hash(name string) (string, error) {
+	info, err := fs.Stat(sf.files, name)
+	if err != nil { return "", err }
+
+	sf.lock.Lock()
+	h, ok := sf.hashes[name]
+	sf.lock.Unlock()
+	if ok && h.size == info.Size() && h.modified.Equal(info.ModTime()) { return h.hash, nil }
+
+	f, err := sf.files.Open(name)
+	if err != nil { return "", err }
+	defer f.Close()
+	sum := sha256.New()
+	if _, err := io.Copy(sum, f); err != nil { return "", err }
+
+	h = file_hash{ size: info.Size(), modified: info.ModTime(), hash: hex.EncodeToString(sum.Sum(nil))[:16] }
+	sf.lock.Lock()
+	sf.hashes[name] = h
+	sf.lock.Unlock()
+	return h.hash, nil
+}
+
+// The files index.html loads, like src="wasm_exec.js" and wasm_filename = "main.wasm"
+
+var page_urls *regexp.Regexp = regexp.MustCompile(`((?:src|href)=|wasm_filename = )"(?:\./)?([^"?#:]+)"`)
+
+// index.html, with the hashes added to the URLs of the files it loads
+
+func (sf *static_files) page(name string) ([]byte, error) {
+	b, err := fs.ReadFile(sf.files, name)
+	if err != nil { return nil, err }
+
+	dir := path.Dir(name)
+	b = page_urls.ReplaceAllFunc(b, func(m []byte) []byte {
+		parts := page_urls.FindSubmatch(m)
+		url := string(parts[2])
+		h, err := sf.hash(path.Join(dir, url))
+		if err != nil { return m }	// a file that isn't there, or a directory
+		return []byte(string(parts[1]) + `"` + url + "?v=" + h + `"`)
+	})
+	if sf.inject != "" {
+		if i := bytes.LastIndex(b, []byte("</body>")); i >= 0 {
+			b = append(b[:i:i], append([]byte(sf.inject), b[i:]...)...)
+		}
+	}
+	return b, nil
+}
+
+func accepts(r *http.Request, encoding string) bool {
+	for _, e := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		e, q, _ := strings.Cut(strings.TrimSpace(e), ";")
+		if strings.TrimSpace(e) == encoding && strings.ReplaceAll(q, " ", "") != "q=0" { return true }
+	}
+	return false
+}
+
+func (sf *static_files) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := strings.TrimPrefix(path.Clean("/" + r.URL.Path), "/")
+	if strings.HasSuffix(r.URL.Path, "/") { name = path.Join(name, "index.html") }
+	if name == "" { name = "index.html" }
+
+	info, err := fs.Stat(sf.files, name)
+	if err != nil || info.IsDir() {
+		http.NotFound(w, r)
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", content_type(name))
+
+	/* the page is changed, so it has its own hash */
+	if path.Base(name) == "index.html" {
+		b, err := sf.page(name)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sum := sha256.Sum256(b)
+		h.Set("ETag", `"` + hex.EncodeToString(sum[:8]) + `"`)
+		h.Set("Cache-Control", cache_check)
+		// no modification time, since it changes when the files it loads do
+		http.ServeContent(w, r, name, time.Time{}, bytes.NewReader(b))
+		return
+	}
+
+	hash, err := sf.hash(name)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if r.URL.Query().Get("v") == hash { h.Set("Cache-Control", cache_forever) } else { h.Set("Cache-Control", cache_check) }
+	h.Add("Vary", "Accept-Encoding")
+
+	/* a compressed version, if it's there and wasn't made from an older file */
+	served := name
+	sum, _ := fs.ReadFile(sf.files, name + ".sum")
+	current := strings.TrimSpace(string(sum)) == hash
+	for _, e := range encodings {
+		if !current || !accepts(r, e.name) { continue }
+		ci, err := fs.Stat(sf.files, name + e.ext)
+		if err != nil || ci.IsDir() { continue }
+		served = name + e.ext
+		h.Set("Content-Encoding", e.name)
+		hash += "-" + e.name	// it's different from the uncompressed file
+		break
+	}
+	h.Set("ETag", `"` + hash + `"`)
+
+	f, err := sf.files.Open(served)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer f.Close()
+	if rs, ok := f.(io.ReadSeeker); ok {
+		http.ServeContent(w, r, name, info.ModTime(), rs)
+		return
+	}
+	b, err := io.ReadAll(f)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.ServeContent(w, r, name, info.ModTime(), bytes.NewReader(b))
+}
@@ -0,0 +1,95 @@
+//go:build !js
+
+package main
+
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func static_get(t *testing.T, sf *static_files, url string, headers ...string) (*http.Response, string) {
+	t.Helper()
+	r := httptest.NewRequest("GET", url, nil)
+	for i := 0; i+1 < len(headers); i += 2 { r.Header.Set(headers[i], headers[i+1]) }
+	w := httptest.NewRecorder()
+	sf.ServeHTTP(w, r)
+	b, _ := io.ReadAll(w.Result().Body)
+	return w.Result(), string(b)
+}
+
+func TestStaticFiles(t *testing.T) {
+	now := time.Now()
+	files := fstest.MapFS{
+		"index.html":    { Data: []byte(`<script src="wasm_exec.js"></script> wasm_filename = "main.wasm"; <img src="img/missing.png">`), ModTime: now },
+		"wasm_exec.js":  { Data: []byte("// glue"), ModTime: now },
+		"main.wasm":     { Data: []byte("\x00asm wasm"), ModTime: now },
+		"main.wasm.gz":  { Data: []byte("gzipped"), ModTime: now },
+		"main.wasm.br":  { Data: []byte("brotli"), ModTime: now },
+	}
+	sf := new_static_files(files)
+	hash, _ := sf.hash("main.wasm")
+	files["main.wasm.sum"] = &fstest.MapFile{ Data: []byte(hash + "\n") }	// as "make compress" makes it
+
+	/* the page has the hashes in its URLs */
+	r, page := static_get(t, sf, "/")
+	if r.StatusCode != http.StatusOK || !strings.HasPrefix(r.Header.Get("Content-Type"), "text/html") {
+		t.Fatalf("index.html: %d %q", r.StatusCode, r.Header.Get("Content-Type"))
+	}
+	if !strings.Contains(page, `wasm_filename = "main.wasm?v=` + hash + `"`) || !strings.Contains(page, `src="wasm_exec.js?v=`) ||
+		!strings.Contains(page, `src="img/missing.png"`) {
+		t.Errorf("index.html: %s", page)
+	}
+	if r.Header.Get("Cache-Control") != cache_check || r.Header.Get("ETag") == "" {
+		t.Errorf("index.html headers: %v", r.Header)
+	}
+
+	/* the type, the brotli or gzip version, and caching */
+	r, body := static_get(t, sf, "/main.wasm?v=" + hash, "Accept-Encoding", "gzip, deflate, br")
+	if r.Header.Get("Content-Type") != "application/wasm" || r.Header.Get("Content-Encoding") != "br" || body != "brotli" {
+		t.Errorf("main.wasm: %v %q", r.Header, body)
+	}
+	r, body = static_get(t, sf, "/main.wasm?v=" + hash, "Accept-Encoding", "gzip, deflate")
+	if r.Header.Get("Content-Encoding") != "gzip" || body != "gzipped" {
+		t.Errorf("main.wasm with gzip: %v %q", r.Header, body)
+	}
+	if r.Header.Get("Cache-Control") != cache_forever || r.Header.Get("ETag") != `"` + hash + `-gzip"` {
+		t.Errorf("main.wasm caching: %v", r.Header)
+	}
+
+	r, body = static_get(t, sf, "/main.wasm?v=old")
+	if r.Header.Get("Content-Encoding") != "" || body != "\x00asm wasm" || r.Header.Get("Cache-Control") != cache_check {
+		t.Errorf("main.wasm without compression: %v %q", r.Header, body)
+	}
+	if r, _ = static_get(t, sf, "/main.wasm", "If-None-Match", `"` + hash + `"`); r.StatusCode != http.StatusNotModified {
+		t.Errorf("If-None-Match: %d", r.StatusCode)
+	}
+	if r, _ = static_get(t, sf, "/main.wasm", "Accept-Encoding", "gzip;q=0"); r.Header.Get("Content-Encoding") != "" {
+		t.Errorf("gzip;q=0: %v", r.Header)
+	}
+
+	/* the compressed versions aren't used after main.wasm changes, even without modification times */
+	files["main.wasm"] = &fstest.MapFile{ Data: []byte("\x00asm new wasm") }
+	new_hash, _ := sf.hash("main.wasm")
+	r, body = static_get(t, sf, "/main.wasm?v=" + new_hash, "Accept-Encoding", "gzip, br")
+	if r.Header.Get("Content-Encoding") != "" || body != "\x00asm new wasm" || r.Header.Get("ETag") != `"` + new_hash + `"` {
+		t.Errorf("main.wasm after it changed: %v %q", r.Header, body)
+	}
+
+	for _, url := range []string{ "/nothing.js", "/../main.wasm.gz/x", "/img/" } {
+		if r, _ = static_get(t, sf, url); r.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: %d", url, r.StatusCode)
+		}
+	}
+}
+
+func TestEmbeddedFiles(t *testing.T) {
+	for _, name := range []string{ "index.html", "wasm_exec.js", "main.wasm", "css/styles.css", "img/nocard.png" } {
+		if _, err := fs.Stat(game_files, name); err != nil { t.Errorf("%s isn't built in: %v", name, err) }
+	}
+}
@@ -0,0 +1,134 @@
+//go:build js && wasm
+
+// SVG playing cards for Video Poker
+
+// Any card can be drawn from its value (Card.Rank()) and suit, as SVG markup
+// that is put right into the page, so the cards are sharp at any size and
+// don't need image files. The layout follows real playing cards: the value and
+// suit in two corners, and the pips (suit symbols) arranged in the middle,
+// with the ones in the bottom half upside down. Jacks, queens and kings get
+// a frame with a big letter instead of a picture.
+
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+// How the cards are drawn
+
+type svg_style struct {
+	colors [vp.NUMSUITS]string	// colour of each suit
+	large bool		// draw a big value and suit instead of pips, for small screens
+}
+
+// Suit symbols and value characters for drawing the cards
+
+var suitsymbol [vp.NUMSUITS]string = [vp.NUMSUITS]string { "♣", "♦", "♥", "♠" }
+
+var rankchar [vp.ACE+1]string = [vp.ACE+1]string {
+	"", "2", "3", "4", "5", "6", "7", "8", "9", "10", "J", "Q", "K", "A",
+}
+
+// Suit colours: the usual red and black, and the four-colour deck,
+// which has blue diamonds and green clubs so the suits are easy to tell apart
+
+var twocolors [vp.NUMSUITS]string = [vp.NUMSUITS]string { "black", "#d00", "#d00", "black" }
+var fourcolors [vp.NUMSUITS]string = [vp.NUMSUITS]string { "#080", "#00c", "#d00", "black" }
+
+// Where the pips go for each card value from vp.TWO to vp.TEN.
+// The card is 100 wide and 145 high, like the PNG cards, and the pips
+// are in three columns (30, 50, 70) and up to seven rows.
+
+const (
+	pip_left = 30
+	pip_center = 50
+	pip_right = 70
+
+	pip_top = 30
+	pip_mid = 72.5
+	pip_bottom = 115
+)
+
+type pip struct { x, y float64 }
+
+var piplayouts [vp.TEN+1][]pip = [vp.TEN+1][]pip {
+	vp.TWO:   { {pip_center, pip_top}, {pip_center, pip_bottom} },
+	vp.THREE: { {pip_center, pip_top}, {pip_center, pip_mid}, {pip_center, pip_bottom} },
+	vp.FOUR:  { {pip_left, pip_top}, {pip_right, pip_top}, {pip_left, pip_bottom}, {pip_right, pip_bottom} },
+	vp.FIVE:  { {pip_left, pip_top}, {pip_right, pip_top}, {pip_center, pip_mid},
+		{pip_left, pip_bottom}, {pip_right, pip_bottom} },
+	vp.SIX:   { {pip_left, pip_top}, {pip_right, pip_top}, {pip_left, pip_mid}, {pip_right, pip_mid},
+		{pip_left, pip_bottom}, {pip_right, pip_bottom} },
+	vp.SEVEN: { {pip_left, pip_top}, {pip_right, pip_top}, {pip_center, 51}, {pip_left, pip_mid},
+		{pip_right, pip_mid}, {pip_left, pip_bottom}, {pip_right, pip_bottom} },
+	vp.EIGHT: { {pip_left, pip_top}, {pip_right, pip_top}, {pip_center, 51}, {pip_left, pip_mid},
+		{pip_right, pip_mid}, {pip_center, 94}, {pip_left, pip_bottom}, {pip_right, pip_bottom} },
+	vp.NINE:  { {pip_left, pip_top}, {pip_right, pip_top}, {pip_left, 58}, {pip_right, 58},
+		{pip_center, pip_mid}, {pip_left, 87}, {pip_right, 87}, {pip_left, pip_bottom}, {pip_right, pip_bottom} },
+	vp.TEN:   { {pip_left, pip_top}, {pip_right, pip_top}, {pip_center, 44}, {pip_left, 58}, {pip_right, 58},
+		{pip_left, 87}, {pip_right, 87}, {pip_center, 101}, {pip_left, pip_bottom}, {pip_right, pip_bottom} },
+}
+
+// The SVG markup for a card
+
+func card_svg(c vp.Card, style svg_style) string {
+	var b strings.Builder
+
+	b.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" width="100" height="145" viewBox="0 0 100 145">`)
+	b.WriteString(`<rect x="1" y="1" width="98" height="143" rx="8" fill="white" stroke="#888"/>`)
+
+	color := style.colors[c.Suit()]
+	rank := rankchar[c.Rank()]
+	suit := suitsymbol[c.Suit()]
+
+	fmt.Fprintf(&b, `<g fill="%s" font-family="Verdana, sans-serif" text-anchor="middle">`, color)
+	switch {
+		case style.large:
+			fmt.Fprintf(&b, `<text x="50" y="66" font-size="60" font-weight="bold">%s</text>`, rank)
+			fmt.Fprintf(&b, `<text x="50" y="130" font-size="60">%s</text>`, suit)
+		case c.Rank() == vp.ACE:
+			svg_corners(&b, rank, suit)
+			fmt.Fprintf(&b, `<text x="50" y="92" font-size="60">%s</text>`, suit)
+		case c.Rank() >= vp.JACK:
+			svg_corners(&b, rank, suit)
+			svg_court(&b, color, rank, suit)
+		default:
+			svg_corners(&b, rank, suit)
+			svg_pips(&b, c.Rank(), suit)
+	}
+	b.WriteString(`</g>`)
+	b.WriteString(`</svg>`)
+	return b.String()
+}
+
+// The value and suit in the top left corner, and upside down in the bottom right
+
+func svg_corners(b *strings.Builder, rank, suit string) {
+	corner := fmt.Sprintf(`<text x="11" y="20" font-size="16" font-weight="bold">%s</text>` +
+		`<text x="11" y="36" font-size="14">%s</text>`, rank, suit)
+	b.WriteString(corner)
+	fmt.Fprintf(b, `<g transform="rotate(180 50 72.5)">%s</g>`, corner)
+}
+
+func svg_pips(b *strings.Builder, index int, suit string) {
+	for _, p := range piplayouts[index] {
+		if p.y > pip_mid {
+			fmt.Fprintf(b, `<text x="%g" y="%g" font-size="22" transform="rotate(180 %g %g)">%s</text>`,
+				p.x, p.y + 8, p.x, p.y, suit)
+		} else {
+			fmt.Fprintf(b, `<text x="%g" y="%g" font-size="22">%s</text>`, p.x, p.y + 8, suit)
+		}
+	}
+}
+
+// Jack, queen and king: a frame with the letter and suit in it
+
+func svg_court(b *strings.Builder, color, rank, suit string) {
+	fmt.Fprintf(b, `<rect x="22" y="18" width="56" height="109" fill="none" stroke="%s" stroke-width="2"/>`, color)
+	fmt.Fprintf(b, `<text x="50" y="78" font-size="44" font-weight="bold">%s</text>`, rank)
+	fmt.Fprintf(b, `<text x="50" y="112" font-size="26">%s</text>`, suit)
+}
@@ -0,0 +1,78 @@
+//go:build js && wasm
+
+// Card themes for Video Poker
+
+// A theme decides what the cards look like, by giving the markup for each card,
+// which is put inside the card's <span> in index.html.
+// The "classic" theme is the set of PNG files in img/. The others are SVG cards,
+// drawn from the card's value and suit by svgcards.go, so they don't need image files.
+//
+// The theme is chosen in the Settings panel, and saved in localStorage.
+
+package main
+
+import (
+	"syscall/js"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+	)
+
+// A theme is a function that gives the markup for a card
+
+type card_theme func(c vp.Card) string
+
+var themes map[string]card_theme = map[string]card_theme {
+	"classic":   classic_card,
+	"standard":  func(c vp.Card) string { return card_svg(c, svg_style{ twocolors, false }) },
+	"fourcolor": func(c vp.Card) string { return card_svg(c, svg_style{ fourcolors, false }) },
+	"large":     func(c vp.Card) string { return card_svg(c, svg_style{ twocolors, true }) },
+}
+
+const default_theme = "classic"
+const theme_storage = "videopoker.theme"
+
+var theme card_theme = classic_card
+
+// The markup for a card in the theme being used.
+// The transparent card before the first deal, and face down cards, are always the same.
+
+const nocard_markup = `<img src="img/nocard.png" width="100" height="145" draggable="false" alt="">`
+
+func card_markup(c vp.Card) string {
+	if c.Blank() { return nocard_markup }
+	return theme(c)
+}
+
+// The PNG files in img/
+
+func classic_card(c vp.Card) string {
+	return `<img src="img/` + c.Image() + `" width="100" height="145" draggable="false" alt="">`
+}
+
+func set_theme(name string) {
+	t, ok := themes[name]
+	if !ok { return }
+	theme = t
+	js.Global().Get("localStorage").Call("setItem", theme_storage, name)
+	js.Global().Get("document").Call("getElementById", "theme").Set("value", name)
+}
+
+func load_theme() {
+	saved := js.Global().Get("localStorage").Call("getItem", theme_storage)
+	if saved.IsNull() {
+		set_theme(default_theme)
+	} else {
+		set_theme(saved.String())
+	}
+}
+
+// Callback for the card theme menu in the Settings panel: cardtheme("fourcolor")
+// The cards on the table are redrawn right away.
+
+func choose_theme(this js.Value, args []js.Value) interface{} {
+	js.Global().Get("document").Get("activeElement").Call("blur")
+	if len(args) < 1 { return nil }
+	set_theme(args[0].String())
+	if !drawing() { GUI_update_hand() }
+	return nil
+}
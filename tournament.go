@@ -0,0 +1,319 @@
+//go:build !js
+
+// Tournaments, played on the server
+//
+// A tournament has a variant, a number of hands, the chips each entrant starts
+// with, and a secret seed. Every entrant is dealt the same hands, and gets the same
+// cards when they draw (see Session.FixDeals in videopoker/session.go), so the
+// only difference between them is how they play. Each entrant plays a session with
+// the usual API (see apiserver.go), which ends by itself after the last hand.
+// Each name can enter once, and the sessions can't be watched (see spectate.go).
+// The tournament closes at its deadline, and the sessions that haven't ended are
+// ended then. The seed is shown after it closes, so the deals can be checked.
+//
+//	POST /api/tournament			{ "name": "Friday", "variant": "JacksOrBetter", "hands": 100,
+//						  "bankroll": 1000, "seed": 12345, "deadline": "2026-10-23T17:00:00Z" }
+//	GET  /api/tournament/{tid}		the tournament, and the standings so far
+//	POST /api/tournament/{tid}/enter	{ "name": "Kim", "lang": "en" }, which starts a session (with "limits" too, as in apiserver.go)
+//
+// If the server was started with -tournament-key, creating a tournament needs
+// the header "Authorization: Bearer <key>". Tournaments are kept until a day after
+// they close, and are lost when the server stops.
+
+package main
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+)
+
+const max_tournaments = 100
+const max_hands = 10000
+const max_bankroll = 1000000
+const tournament_kept = 24 * time.Hour
+
+type tournament struct {
+	id string
+	name string
+	game int
+	hands int
+	bankroll int
+	seed int64
+	deadline time.Time
+
+	lock sync.Mutex
+	entrants []*entrant
+	closed time.Time	// zero until it closes
+}
+
+type entrant struct {
+	t *tournament
+	session *api_session
+	name string
+	chips int
+	hands int
+	finished time.Time	// zero until the session ends
+}
+
+type tournament_request struct {
+	Name string	`json:"name"`
+	Variant string	`json:"variant"`
+	Hands int	`json:"hands"`
+	Bankroll int	`json:"bankroll"`
+	Seed *int64	`json:"seed"`	// random if it's not given
+	Deadline time.Time `json:"deadline"`
+}
+
+type standing struct {
+	Rank int	`json:"rank"`
+	Name string	`json:"name"`
+	Chips int	`json:"chips"`
+	Hands int	`json:"hands"`
+	Finished bool	`json:"finished"`
+}
+
+type tournament_response struct {
+	ID string		`json:"id"`
+	Name string		`json:"name"`
+	Variant string		`json:"variant"`
+	Hands int		`json:"hands"`
+	Bankroll int		`json:"bankroll"`
+	Deadline time.Time	`json:"deadline"`
+	Closed bool		`json:"closed"`
+	Seed *int64		`json:"seed,omitempty"`	// after it closes
+	Standings []standing	`json:"standings"`
+}
+
+var tournaments map[string]*tournament = map[string]*tournament{}
+var tournaments_lock sync.Mutex
+
+// The key for creating tournaments, or "" if anyone can (see webserver.go)
+
+var tournament_key string
+
+func register_tournaments(mux *http.ServeMux) {
+	mux.HandleFunc("POST /api/tournament", api_new_tournament)
+	mux.HandleFunc("GET /api/tournament/{tid}", api_tournament)
+	mux.HandleFunc("POST /api/tournament/{tid}/enter", api_enter)
+}
+
+func find_tournament(w http.ResponseWriter, r *http.Request) *tournament {
+	tournaments_lock.Lock()
+	t := tournaments[r.PathValue("tid")]
+	tournaments_lock.Unlock()
+	if t == nil { api_error(w, http.StatusNotFound, "no such tournament") }
+	return t
+}
+
+// Entrants are ranked by their chips, then by who finished first
+
+func (t *tournament) standings() []standing {
+	t.lock.Lock()
+	list := make([]*entrant, len(t.entrants))
+	copy(list, t.entrants)
+	sort.SliceStable(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.chips != b.chips { return a.chips > b.chips }
+		if a.finished.IsZero() != b.finished.IsZero() { return !a.finished.IsZero() }
+		return a.finished.Before(b.finished)
+	})
+	ranked := make([]standing, len(list))
+	for i, e := range list {
+		ranked[i] = standing{ i+1, e.name, e.chips, e.hands, !e.finished.IsZero() }
+		if i > 0 && ranked[i].Chips == ranked[i-1].Chips { ranked[i].Rank = ranked[i-1].Rank }	// a tie
+	}
+	t.lock.Unlock()
+	return ranked
+}
+
+func (t *tournament) response() tournament_response {
+	resp := tournament_response{ ID: t.id, Name: t.name, Variant: vp.GameID(t.game), Hands: t.hands,
+		Bankroll: t.bankroll, Deadline: t.deadline, Standings: t.standings() }
+	t.lock.Lock()
+	if !t.closed.IsZero() {
+		resp.Closed = true
+		seed := t.seed
+		resp.Seed = &seed
+	}
+	t.lock.Unlock()
+	return resp
+}
+
+func (t *tournament) is_closed() bool {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return !t.closed.IsZero()
+}
+
+// Close the tournament at its deadline, and end the sessions that haven't ended
+
+func (t *tournament) close() {
+	t.lock.Lock()
+	if !t.closed.IsZero() {
+		t.lock.Unlock()
+		return
+	}
+	t.closed = time.Now()
+	entrants := make([]*entrant, len(t.entrants))
+	copy(entrants, t.entrants)
+	t.lock.Unlock()
+
+	for _, e := range entrants {
+		var state vp.Snapshot
+		e.session.game.Do(vp.NoView{}, func() {
+			vp.Quit()
+			state = vp.GetState()
+		})
+		e.update(state)
+	}
+}
+
+// The refusal of an action in a tournament, or ""
+
+func (e *entrant) refuse(action string) string {
+	switch {
+		case e.t.is_closed(): return "the tournament is over"
+		case action == "game": return "the game can't be changed in a tournament"
+		case action == "new": return "a tournament has only one session for each entry"
+	}
+	return ""
+}
+
+// End the session after its last hand. It's called in the session's Do(), after each action.
+
+func (e *entrant) check_hands() {
+	if vp.HandsPlayed() >= e.t.hands && vp.State() != vp.Over { vp.Quit() }
+}
+
+// Keep the entrant's standing up to date
+
+func (e *entrant) update(state vp.Snapshot) {
+	e.t.lock.Lock()
+	e.chips = state.Score
+	e.hands = state.HandsPlayed
+	if state.Phase == "over" && e.finished.IsZero() { e.finished = time.Now() }
+	e.t.lock.Unlock()
+}
+
+// POST /api/tournament
+
+func api_new_tournament(w http.ResponseWriter, r *http.Request) {
+	if tournament_key != "" && subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), []byte("Bearer " + tournament_key)) != 1 {
+		api_error(w, http.StatusUnauthorized, "creating a tournament needs the key")
+		return
+	}
+	var req tournament_request
+	if !read_json(w, r, &req) { return }
+
+	t := &tournament{ id: new_id(), name: player_name(req.Name), game: vp.JacksOrBetter, hands: req.Hands,
+		bankroll: req.Bankroll, deadline: req.Deadline }
+	if req.Variant != "" {
+		var err error
+		if t.game, err = vp.FindGame(req.Variant); err != nil {
+			api_error(w, http.StatusBadRequest, err.Error())
+			return
+		}
+	}
+	if t.hands == 0 { t.hands = 100 }
+	if t.bankroll == 0 { t.bankroll = vp.INITCHIPS }
+	if t.deadline.IsZero() { t.deadline = time.Now().Add(24 * time.Hour) }
+	if req.Seed != nil { t.seed = *req.Seed } else { t.seed = random_seed() }
+	switch {
+		case t.hands < 1 || t.hands > max_hands:
+			api_error(w, http.StatusBadRequest, "hands must be from 1 to 10000")
+			return
+		case t.bankroll < vp.INITMINBET || t.bankroll > max_bankroll:
+			api_error(w, http.StatusBadRequest, "bankroll must be from 10 to 1000000")
+			return
+		case !t.deadline.After(time.Now()):
+			api_error(w, http.StatusBadRequest, "the deadline has passed")
+			return
+	}
+
+	tournaments_lock.Lock()
+	if len(tournaments) >= max_tournaments {
+		tournaments_lock.Unlock()
+		api_error(w, http.StatusServiceUnavailable, "too many tournaments")
+		return
+	}
+	tournaments[t.id] = t
+	tournaments_lock.Unlock()
+	time.AfterFunc(time.Until(t.deadline), t.close)
+
+	api_json(w, http.StatusOK, t.response())
+}
+
+// GET /api/tournament/{tid}
+
+func api_tournament(w http.ResponseWriter, r *http.Request) {
+	t := find_tournament(w, r)
+	if t == nil { return }
+	api_json(w, http.StatusOK, t.response())
+}
+
+// POST /api/tournament/{tid}/enter
+
+func api_enter(w http.ResponseWriter, r *http.Request) {
+	t := find_tournament(w, r)
+	if t == nil { return }
+	req, ok := read_request(w, r)
+	if !ok { return }
+	if t.is_closed() {
+		api_error(w, http.StatusConflict, "the tournament is over")
+		return
+	}
+
+	script := &vp.Script{}
+	s := &api_session{ game: vp.NewSession(script, t.game, random_seed(), req.Lang), name: player_name(req.Name), used: time.Now() }
+	s.game.FixDeals(t.seed)
+	s.game.SetChips(t.bankroll)
+	s.entry = &entrant{ t: t, session: s, name: s.name, chips: t.bankroll }
+
+	// Each name can enter once. Otherwise a player could enter to see the cards,
+	// and enter again knowing them.
+	t.lock.Lock()
+	for _, e := range t.entrants {
+		if strings.EqualFold(e.name, s.name) {
+			t.lock.Unlock()
+			api_error(w, http.StatusConflict, "that name has already entered the tournament")
+			return
+		}
+	}
+	t.entrants = append(t.entrants, s.entry)
+	t.lock.Unlock()
+
+	id, ok := add_session(w, s)
+	if !ok {
+		t.lock.Lock()
+		for i, e := range t.entrants {
+			if e == s.entry { t.entrants = append(t.entrants[:i], t.entrants[i+1:]...); break }
+		}
+		t.lock.Unlock()
+		return
+	}
+	restore_limits(s, script, req.Limits)
+
+	resp := api_response{ ID: id, Calls: script.Calls }
+	s.game.Do(script, resp.get_state)
+	count("game_sessions_started_total", "", 1)
+	api_reply(w, http.StatusOK, resp)
+}
+
+// Forget the tournaments that closed a while ago
+
+func forget_tournaments() {
+	tournaments_lock.Lock()
+	defer tournaments_lock.Unlock()
+	for id, t := range tournaments {
+		t.lock.Lock()
+		old := !t.closed.IsZero() && time.Since(t.closed) > tournament_kept
+		t.lock.Unlock()
+		if old { delete(tournaments, id) }
+	}
+}
@@ -0,0 +1,115 @@
+//go:build !js
+
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
+)
+
+func get_tournament(t *testing.T, server *httptest.Server, tid string) tournament_response {
+	t.Helper()
+	var resp tournament_response
+	r, err := http.Get(server.URL + "/api/tournament/" + tid)
+	if err != nil { t.Fatal(err) }
+	defer r.Body.Close()
+	json.NewDecoder(r.Body).Decode(&resp)
+	return resp
+}
+
+func TestTournament(t *testing.T) {
+	vp.Console = io.Discard
+	mux := http.NewServeMux()
+	register_api(mux)
+	server := httptest.NewServer(mux)
+	defer server.Close()
+
+	/* the key is needed when there is one */
+	tournament_key = "sesame"
+	defer func() { tournament_key = "" }()
+	body := `{"name":"Friday","variant":"BonusPoker","hands":3,"bankroll":200,"seed":99}`
+	r, _ := http.Post(server.URL + "/api/tournament", "application/json", strings.NewReader(body))
+	if r.StatusCode != http.StatusUnauthorized { t.Errorf("without the key: %d", r.StatusCode) }
+	req, _ := http.NewRequest("POST", server.URL + "/api/tournament", strings.NewReader(body))
+	req.Header.Set("Authorization", "Bearer sesame")
+	r, err := http.DefaultClient.Do(req)
+	if err != nil { t.Fatal(err) }
+	var tr tournament_response
+	json.NewDecoder(r.Body).Decode(&tr)
+	r.Body.Close()
+	if r.StatusCode != http.StatusOK || tr.Variant != "BonusPoker" || tr.Hands != 3 || tr.Seed != nil {
+		t.Fatalf("new tournament: %d %+v", r.StatusCode, tr)
+	}
+
+	/* two entrants, who hold different cards */
+	var ids [2]string
+	var deals [2][]string
+	holds := []string{ `{"holds":[false,false,false,false,false]}`, `{"holds":[true,true,false,false,false]}` }
+	for i, name := range []string{ "Kim", "Lee" } {
+		status, resp := api_post(t, server, "/api/tournament/" + tr.ID + "/enter", `{"name":"` + name + `"}`)
+		if status != http.StatusOK || resp.State.Score != 200 || resp.State.Variant != "BonusPoker" || resp.Watch != "" {
+			t.Fatalf("enter %s: %d %+v", name, status, resp)
+		}
+		ids[i] = "/api/session/" + resp.ID
+		if status, _ = api_post(t, server, ids[i] + "/game", `{"variant":"JacksOrBetter"}`); status != http.StatusConflict {
+			t.Errorf("changing the game: %d", status)
+		}
+		for hand := 0; hand < 3; hand++ {
+			_, resp = api_post(t, server, ids[i] + "/deal", ``)
+			deals[i] = append(deals[i], strings.Join(resp.State.Hand, " "))
+			_, resp = api_post(t, server, ids[i] + "/draw", holds[i])
+		}
+		if resp.State.Phase != "over" || resp.State.HandsPlayed != 3 {
+			t.Errorf("%s after the last hand: %+v", name, resp.State)
+		}
+	}
+	for hand := range deals[0] {
+		if deals[0][hand] != deals[1][hand] { t.Errorf("hand %d: different deals %v and %v", hand+1, deals[0][hand], deals[1][hand]) }
+	}
+
+	tr = get_tournament(t, server, tr.ID)
+	if len(tr.Standings) != 2 || !tr.Standings[0].Finished || tr.Standings[0].Chips < tr.Standings[1].Chips || tr.Closed {
+		t.Errorf("standings: %+v", tr)
+	}
+
+	/* each name enters once */
+	if status, _ := api_post(t, server, "/api/tournament/" + tr.ID + "/enter", `{"name":" kim "}`); status != http.StatusConflict {
+		t.Errorf("entering again: %d", status)
+	}
+
+	/* a third entrant, whose session is ended when the tournament closes */
+	_, resp := api_post(t, server, "/api/tournament/" + tr.ID + "/enter", `{"name":"Max"}`)
+	api_post(t, server, "/api/session/" + resp.ID + "/deal", ``)
+	tournaments_lock.Lock()
+	tournaments[tr.ID].close()
+	tournaments_lock.Unlock()
+	if status, _ := api_post(t, server, "/api/session/" + resp.ID + "/draw", holds[0]); status != http.StatusConflict {
+		t.Errorf("playing after it closed: %d", status)
+	}
+	if status, _ := api_post(t, server, "/api/tournament/" + tr.ID + "/enter", `{}`); status != http.StatusConflict {
+		t.Errorf("entering after it closed: %d", status)
+	}
+	tr = get_tournament(t, server, tr.ID)
+	if !tr.Closed || tr.Seed == nil || *tr.Seed != 99 || len(tr.Standings) != 3 {
+		t.Fatalf("closed: %+v", tr)
+	}
+	for _, s := range tr.Standings {
+		if !s.Finished { t.Errorf("not finished: %+v", s) }
+	}
+
+	/* bad tournaments */
+	tournament_key = ""
+	for _, body := range []string{ `{"hands":0.5}`, `{"hands":20000}`, `{"bankroll":5}`, `{"variant":"Blackjack"}`,
+		`{"deadline":"` + time.Now().Add(-time.Hour).Format(time.RFC3339) + `"}` } {
+		if status, _ := api_post(t, server, "/api/tournament", body); status != http.StatusBadRequest {
+			t.Errorf("%s: %d", body, status)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+// The interface between the engine and the front ends
+
+// A front end (the web page, or a terminal) gives the engine a View with Start(),
+// then plays the game by calling the functions below when the player
+// clicks or types something, and reads the state of the game with the others.
+// The engine isn't safe to use from more than one goroutine at a time.
+
+package videopoker
+
+import (
+	"fmt"
+	"strings"
+	)
+
+/* Start the game, showing it with v */
+
+func Start(v View) {
+//
+	view = v
+	videopoker()
+}
+
+/* Play with a key, like in text mode (see key_action()) */
+
+func Key(key byte) {
+//
+	key_action(key)
+}
+
+/* The Deal/Draw button: deal, draw, or start a new session, depending on the state */
+
+func DealOrDraw() {
+//
+	key_action(key_Return)
+}
+
+/* Hold or un-hold card n, from 0 to 4 */
+
+func ToggleHold(n int) {
+//
+	if n < 0 || n >= CARDS || state == Over { return }
+	toggle_hold(n)
+}
+
+/* Bet m times the minimum bet, m from 1 to 5 */
+
+func Bet(m int) {
+//
+	if m < 1 || m > 5 || state == Over { return }
+	do_bet(byte(key_0 + m))
+}
+
+func Quit() {
+//
+	if state == Over { return }
+	do_quit()
+}
+
+/* Change to game g (AllAmerican, TensOrBetter, etc.), which starts a new session */
+
+func ChangeGame(g int) {
+//
+	changegame(g)
+}
+
+/* The state of the game */
+
+func State() int		{ return state }
+func Hand() [CARDS]Card		{ return hand }
+func Held(n int) bool		{ return hold[n] != 0 }
+func Score() int		{ return score }
+func BetMultiplier() int	{ return betmultiplier }
+func MinBet() int		{ return minbet }
+func CurrentGame() int		{ return game }
+func HandsPlayed() int		{ return hands }
+
+/* The chips paid for a hand type, when betting m times the minimum bet */
+
+func Payout(handtype, m int) int {
+//
+	return paytable[handtype] * m * minbet
+}
+
+/* Names of games and hand types, in the player's language */
+
+func GameName(g int) string		{ return tr(gamenames[g]) }
+func GameID(g int) string		{ return gameids[g] }	// like "JacksOrBetter95", the same in every language
+func HandName(handtype int) string	{ return tr(handname[handtype]) }
+
+/* The message that tells the player what to do next */
+
+func StateMessage() string {
+//
+	switch state {
+	//
+		case Draw: return tr(msg_draw)
+		case Over: return tr(msg_over)
+	}
+	if limits.Stopped != "" { return limit_message() }
+	return tr(msg_deal)
+}
+
+/*
+	A snapshot of the game, for programs that use it, like a page the game is embedded in.
+	It is meant to be turned into JSON, so the names don't change with the language.
+*/
+
+type Snapshot struct {
+	Phase string		`json:"phase"`		// "deal", "draw" or "over": what the Deal/Draw button does next
+	Hand []string		`json:"hand"`		// like "10h" or "As", or "" before the first hand
+	Holds []bool		`json:"holds"`
+	Score int		`json:"score"`
+	Bet int			`json:"bet"`		// the number of chips bet on a hand
+	BetMultiplier int	`json:"betMultiplier"`	// 1 to 5
+	Variant string		`json:"variant"`	// the game, like "JacksOrBetter95"
+	VariantName string	`json:"variantName"`	// like "9/5 Jacks or Better"
+	HandsPlayed int		`json:"handsPlayed"`
+	PeakScore int		`json:"peakScore"`	// the most chips in this session
+}
+
+var phasenames []string = []string { Deal: "deal", Draw: "draw", Over: "over" }
+
+func GetState() Snapshot {
+//
+	s := Snapshot{
+		Phase: phasenames[state],
+		Hand: make([]string, CARDS),
+		Holds: make([]bool, CARDS),
+		Score: score,
+		Bet: bet,
+		BetMultiplier: betmultiplier,
+		Variant: gameids[game],
+		VariantName: gamenames[game],
+		HandsPlayed: hands,
+		PeakScore: score_high,
+	}
+	for i := 0; i < CARDS; i++ {
+	//
+		if !hand[i].Blank() { s.Hand[i] = hand[i].String() }
+		s.Holds[i] = hold[i] != 0
+	}
+	return s
+}
+
+/* The phase in a Snapshot for a state */
+
+func Phase(state int) string {
+//
+	return phasenames[state]
+}
+
+/*
+	Put a Snapshot into the game. This is for a front end that shows a game
+	that is being played somewhere else, like on a server.
+*/
+
+func Load(s Snapshot) error {
+//
+	var st int = -1
+	var g int = -1
+
+	for i, p := range phasenames {
+	//
+		if p == s.Phase { st = i }
+	}
+	for i, id := range gameids {
+	//
+		if id == s.Variant { g = i }
+	}
+	if st < 0 { return fmt.Errorf("unknown phase %q", s.Phase) }
+	if g < 0 { return fmt.Errorf("unknown variant %q", s.Variant) }
+	if len(s.Hand) != CARDS || len(s.Holds) != CARDS { return fmt.Errorf("not %d cards", CARDS) }
+	if s.BetMultiplier < 1 || s.BetMultiplier > 5 { return fmt.Errorf("bad bet multiplier %d", s.BetMultiplier) }
+
+	for i := 0; i < CARDS; i++ {
+	//
+		c, ok := ParseCard(s.Hand[i])
+		if !ok { return fmt.Errorf("unknown card %q", s.Hand[i]) }
+		hand[i] = c
+		hold[i] = 0
+		if s.Holds[i] { hold[i] = 1 }
+	}
+	state = st
+	game = g
+	setgame(game)
+	score = s.Score
+	bet = s.Bet
+	betmultiplier = s.BetMultiplier
+	minbet = bet / betmultiplier
+	hands = s.HandsPlayed
+	if s.PeakScore > 0 { score_high = s.PeakScore }
+	if score < score_low || hands == 0 { score_low = score }
+	return nil
+}
+
+/* The card for text like "10h", as in Card.String(). "" is the transparent card. */
+
+func ParseCard(s string) (Card, bool) {
+//
+	if s == "" || s == "--" { return transparent_card, true }
+	for _, c := range all_cards {
+	//
+		if c.String() == s { return c, true }
+	}
+	return transparent_card, false
+}
+
+/* Find a game by its number, its id (like "JacksOrBetter95"), or part of its name */
+
+func FindGame(name string) (int, error) {
+//
+	return find_game(strings.ToLower(name))
+}
+
+/* Translation (see messages.go) */
+
+func Tr(msg string) string			{ return tr(msg) }
+func Number(n int) string			{ return number(n) }
+func Language() string				{ return lang }
+func Languages() []string			{ return languages }
+
+/* Use the best language for the ones wanted, and return its code */
+
+func SetLanguage(wanted ...string) string {
+//
+	lang = match_language(wanted)
+	return lang
+}
+
+/* A card */
+
+type Card = card
+
+func (c card) Rank() int	{ return c.index }	// TWO to ACE
+func (c card) Suit() int	{ return c.suit }	// CLUBS, DIAMONDS, HEARTS or SPADES
+func (c card) Image() string	{ return c.uc }		// the file name of its picture in img/
+func (c card) Name() string	{ return cardname(c) }	// like "ten of hearts"
+
+/* The transparent card that is shown before the first hand is dealt */
+
+func (c card) Blank() bool	{ return c.uc == transparent_card.uc }
+
+/* Like "10h" or "As", as in text mode */
+
+func (c card) String() string {
+//
+	if c.Blank() { return "--" }
+	return strings.TrimSpace(c.sym) + suitname[c.suit]
+}
@@ -0,0 +1,63 @@
+// Tests for the interface to the front ends
+
+package videopoker
+
+import (
+	"encoding/json"
+	"testing"
+	)
+
+func TestGetState(t *testing.T) {
+//
+	start_test(t)
+
+	s := GetState()
+	if s.Phase != "deal" || s.Score != INITCHIPS || s.Variant != "JacksOrBetter" || s.Hand[0] != "" {
+		t.Errorf("before the first hand: %+v", s)
+	}
+
+	Bet(2)
+	DealOrDraw()
+	ToggleHold(1)
+	s = GetState()
+	if s.Phase != "draw" || s.Bet != 20 || s.BetMultiplier != 2 || s.Score != INITCHIPS - 20 {
+		t.Errorf("after dealing: %+v", s)
+	}
+	for i := 0; i < CARDS; i++ {
+	//
+		if s.Hand[i] != hand[i].String() { t.Errorf("card %d is %q, want %q", i, s.Hand[i], hand[i]) }
+		if s.Holds[i] != (i == 1) { t.Errorf("card %d held: %t", i, s.Holds[i]) }
+	}
+
+	/* the names in the JSON are part of the embedding API, so they mustn't change */
+	b, err := json.Marshal(s)
+	if err != nil { t.Fatal(err) }
+	var m map[string]interface{}
+	json.Unmarshal(b, &m)
+	for _, name := range []string{ "phase", "hand", "holds", "score", "bet", "betMultiplier", "variant", "variantName", "handsPlayed" } {
+	//
+		if _, ok := m[name]; !ok { t.Errorf("no %q in %s", name, b) }
+	}
+
+	Quit()
+	if s = GetState(); s.Phase != "over" { t.Errorf("after quitting, the phase is %q", s.Phase) }
+}
+
+/* Load() puts back what GetState() took out */
+
+func TestLoad(t *testing.T) {
+//
+	start_test(t)
+
+	s := GetState()
+	s.Phase, s.Score, s.PeakScore, s.HandsPlayed = "draw", 150, 250, 4
+	s.Hand = []string{ "Ah", "Kh", "Qh", "Jh", "10h" }
+	s.Holds = []bool{ true, true, true, true, false }
+	if err := Load(s); err != nil { t.Fatal(err) }
+	if got := GetState(); got.Phase != "draw" || got.Score != 150 || got.PeakScore != 250 || got.HandsPlayed != 4 ||
+		got.Hand[4] != "10h" || !got.Holds[0] || got.Holds[4] {
+		t.Errorf("after Load: %+v", got)
+	}
+	s.Hand[2] = "Zz"
+	if err := Load(s); err == nil { t.Errorf("Load with the card Zz worked") }
+}
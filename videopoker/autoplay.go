@@ -0,0 +1,84 @@
+// Autoplay: the game plays itself, with a strategy, until something stops it
+
+// A front end calls Step() over and over, with a pause in between so the player can
+// see what happens. One step deals a hand and holds the cards the strategy picks,
+// and the next one draws. Everything is shown on the View, as when a player plays.
+// Step() says when to stop: after a number of hands, a royal flush, a big enough win,
+// or when the chips go below or above an amount. The front end stops it for other
+// reasons, like a key being pressed.
+
+package videopoker
+
+import "fmt"
+
+type Autoplay struct {
+	Simple bool		// hold with SimpleHold(), instead of BestHold()
+	Hands int		// stop after this many hands, or 0 to keep going
+	Royal bool		// stop after a royal flush
+	WinAtLeast int		// stop after winning this many chips or more on a hand, or 0
+	Below int		// stop when the chips go below this, or 0
+	Above int		// stop when the chips go above this, or 0
+
+	// Called now and then while the best hold is found, which takes a while.
+	// A front end with only one thread (like WebAssembly in a browser) can use it
+	// to let other things happen, like keys being pressed.
+	Pause func()
+
+	played int
+}
+
+/* Deal and hold, or draw. Returns why autoplay stopped, or "" to go on. */
+
+func (a *Autoplay) Step() string {
+//
+	switch state {
+	//
+		case Over:
+			return tr("Autoplay stopped, since the session is over")
+
+		case Deal:
+			if a.Hands > 0 && a.played >= a.Hands { return a.stop_after() }
+			deal()
+			if state != Draw { return tr("Autoplay stopped at a limit") }	// see limits.go
+			var held [CARDS]bool
+			if a.Simple { held = SimpleHold(hand, game) } else { held, _ = best_hold(hand, game, a.Pause) }
+			for i := 0; i < CARDS; i++ {
+			//
+				if held[i] { toggle_hold(i) }
+			}
+			return ""
+	}
+
+	before := score
+	draw()
+	a.played++
+	won := score - before
+
+	switch {
+	//
+		case state == Over:
+			return tr("Autoplay stopped, since the session is over")
+		case a.Royal && recognize() == ROYAL:
+			return tr("Autoplay stopped after a royal flush")
+		case a.WinAtLeast > 0 && won >= a.WinAtLeast:
+			return fmt.Sprintf(tr("Autoplay stopped after a win of %s chips"), number(won))
+		case a.Below > 0 && score < a.Below:
+			return fmt.Sprintf(tr("Autoplay stopped with fewer than %s chips"), number(a.Below))
+		case a.Above > 0 && score > a.Above:
+			return fmt.Sprintf(tr("Autoplay stopped with more than %s chips"), number(a.Above))
+		case limits.Stopped != "":
+			return tr("Autoplay stopped at a limit")
+		case a.Hands > 0 && a.played >= a.Hands:
+			return a.stop_after()
+	}
+	return ""
+}
+
+func (a *Autoplay) stop_after() string {
+//
+	return fmt.Sprintf(tr("Autoplay stopped after %s hands"), number(a.played))
+}
+
+/* The hands autoplay has played */
+
+func (a *Autoplay) Played() int	{ return a.played }
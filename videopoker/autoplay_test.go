@@ -0,0 +1,79 @@
+// Tests for autoplay
+
+package videopoker
+
+import (
+	"strings"
+	"testing"
+	)
+
+/* Step until autoplay stops, and return why */
+
+func autoplay(t *testing.T, a *Autoplay) string {
+//
+	for i := 0; i < 1000; i++ {
+	//
+		if msg := a.Step(); msg != "" { return msg }
+	}
+	t.Fatalf("autoplay didn't stop: %+v", a)
+	return ""
+}
+
+/* Deal, and change the hand to the given cards, all held */
+
+func deal_cards(t *testing.T, a *Autoplay, cards string) {
+//
+	if msg := a.Step(); msg != "" || state != Draw { t.Fatalf("not dealt: %q", msg) }
+	for i, name := range strings.Fields(cards) {
+	//
+		hand[i] = find_card(t, name)
+		if hold[i] == 0 { toggle_hold(i) }
+	}
+}
+
+func TestAutoplay(t *testing.T) {
+//
+	r := start_test(t)
+
+	/* a number of hands, with the best holds, pausing while they're found */
+	pauses := 0
+	a := &Autoplay{ Hands: 3, Pause: func() { pauses++ } }
+	if msg := autoplay(t, a); msg != "Autoplay stopped after 3 hands" || hands != 3 || state != Deal || pauses < 3 * 30 {
+		t.Errorf("3 hands: %q, %d played, %d pauses", msg, hands, pauses)
+	}
+	if len(r.Find("Win ")) != 3 || len(r.Find("Sound deal")) != 3 { t.Errorf("shown: %v", r.Events) }
+	if msg := a.Step(); msg != "Autoplay stopped after 3 hands" || hands != 3 { t.Errorf("after stopping: %q", msg) }
+
+	/* the simple strategy holds what SimpleHold() picks */
+	a = &Autoplay{ Simple: true }
+	a.Step()
+	want := SimpleHold(hand, game)
+	for i := 0; i < CARDS; i++ {
+	//
+		if (hold[i] != 0) != want[i] { t.Fatalf("held %v, want %v", hold, want) }
+	}
+	a.Step()
+
+	/* a royal flush, and a big win */
+	a = &Autoplay{ Royal: true }
+	deal_cards(t, a, "Ah Kh Qh Jh 10h")
+	if msg := a.Step(); msg != "Autoplay stopped after a royal flush" { t.Errorf("royal: %q", msg) }
+	a = &Autoplay{ WinAtLeast: 50 }
+	deal_cards(t, a, "Ac Kc Qc Jc 9c")
+	if msg := a.Step(); msg != "Autoplay stopped after a win of 60 chips" { t.Errorf("flush: %q", msg) }
+	deal_cards(t, a, "Jc Jd 5s 8h 2c")
+	if msg := a.Step(); msg != "" { t.Errorf("jacks: %q", msg) }
+
+	/* the chips */
+	if msg := autoplay(t, &Autoplay{ Below: score + 1 }); !strings.HasPrefix(msg, "Autoplay stopped with fewer than") {
+		t.Errorf("below: %q", msg)
+	}
+	if msg := autoplay(t, &Autoplay{ Above: 1 }); msg != "Autoplay stopped with more than 1 chips" { t.Errorf("above: %q", msg) }
+
+	/* a limit, and the end of the session */
+	t.Cleanup(func() { limits = LimitState{} })
+	SetLimits(Limits{ LossLimit: 1 })
+	if msg := autoplay(t, &Autoplay{}); msg != "Autoplay stopped at a limit" || !LimitStopped() { t.Errorf("limit: %q", msg) }
+	AcknowledgeLimit()
+	if msg := (&Autoplay{}).Step(); msg != "Autoplay stopped, since the session is over" { t.Errorf("over: %q", msg) }
+}
@@ -0,0 +1,174 @@
+// Text commands, for playing the game from the browser's Developer Tools console
+//
+//	vp.cmd("deal")
+//	vp.cmd("hold 1 3 5")
+//	vp.cmd("draw")
+//
+// The results are printed with printf(), like the rest of the text mode output.
+
+package videopoker
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	)
+
+var command_help string = `Commands:
+  deal            deal a new hand
+  hold 1 3 5      hold those cards, and no others ("hold" alone holds none)
+  draw            replace the cards that aren't held
+  bet 1-5         bet 1 to 5 times the minimum bet
+  game <name>     change the game, by number or part of its name, like "game bonus"
+  games           list the games
+  show            show the hand, bet and score
+  quit            end the session
+  new             start a new session after quitting
+  limits          show the limits (see limits.go)
+  limits 100 0 60 15
+                  set the loss limit, win goal, minutes of play and minutes between
+                  reality checks, with 0 for none
+  ok              go on after play stops at a limit
+  help            show this list
+`
+
+/* Do a command, and print what happened. Returns an error for a command that can't be done. */
+
+func Command(line string) error {
+//
+	err := command(strings.Fields(strings.ToLower(line)))
+	if err != nil { printf("%v\n", err) }
+	return err
+}
+
+func command(words []string) error {
+//
+	if len(words) == 0 { return fmt.Errorf("no command (try \"help\")") }
+	args := words[1:]
+
+	switch words[0] {
+	//
+		case "help", "?":
+			printf("%s", command_help)
+		case "deal":
+			if state != Deal { return fmt.Errorf("can't deal now: %s", StateMessage()) }
+			deal()
+			if state != Draw { return fmt.Errorf("can't deal now: %s", StateMessage()) }	// a limit was reached
+		case "draw":
+			if state != Draw { return fmt.Errorf("can't draw now: %s", StateMessage()) }
+			draw()
+		case "hold":
+			return command_hold(args)
+		case "bet":
+			if len(args) != 1 { return fmt.Errorf("usage: bet 1-5") }
+			m, err := strconv.Atoi(args[0])
+			if err != nil || m < 1 || m > 5 { return fmt.Errorf("bet must be 1 to 5, not %q", args[0]) }
+			if state != Deal { return fmt.Errorf("the bet can only be changed before the hand is dealt") }
+			do_bet(byte(key_0 + m))
+		case "game":
+			g, err := find_game(strings.Join(args, " "))
+			if err != nil { return err }
+			if state == Draw { return fmt.Errorf("%s", tr("Finish this hand before changing the game")) }
+			changegame(g)
+		case "games":
+			for g := 0; g < NUMGAMES; g++ {
+			//
+				mark := " "
+				if g == game { mark = "*" }
+				printf("%s %2d  %s\n", mark, g, gamenames[g])
+			}
+		case "show":
+			showhand()
+			printf("%s: %s  bet: %d  score: %d\n", gamenames[game], StateMessage(), bet, score)
+		case "quit":
+			if state == Over { return fmt.Errorf("the session has already ended (\"new\" starts another)") }
+			do_quit()
+		case "new":
+			if state != Over { return fmt.Errorf("the session hasn't ended (\"quit\" ends it)") }
+			new_session()
+		case "limits":
+			return command_limits(args)
+		case "ok":
+			if limits.Stopped == "" { return fmt.Errorf("play hasn't stopped at a limit") }
+			AcknowledgeLimit()
+		default:
+			return fmt.Errorf("unknown command %q (try \"help\")", words[0])
+	}
+	return nil
+}
+
+/* Hold the cards numbered in args, from 1 to 5, and un-hold the others */
+
+func command_hold(args []string) error {
+//
+	var want [CARDS]bool
+
+	if state != Draw { return fmt.Errorf("cards can only be held after the hand is dealt") }
+	for _, a := range args {
+	//
+		n, err := strconv.Atoi(a)
+		if err != nil || n < 1 || n > CARDS { return fmt.Errorf("card numbers are 1 to %d, not %q", CARDS, a) }
+		want[n-1] = true
+	}
+	for i := 0; i < CARDS; i++ {
+	//
+		if want[i] != (hold[i] != 0) { toggle_hold(i) }
+	}
+	return nil
+}
+
+/* Show the limits, or set them from the numbers in args */
+
+func command_limits(args []string) error {
+//
+	var l Limits
+
+	if len(args) == 0 {
+	//
+		l = limits.Limits
+		printf("loss limit: %d  win goal: %d  minutes: %d  reality check: %d  net: %d\n",
+			l.LossLimit, l.WinGoal, l.MaxMinutes, l.CheckMinutes, limits.Net)
+		return nil
+	}
+	fields := []*int{ &l.LossLimit, &l.WinGoal, &l.MaxMinutes, &l.CheckMinutes }
+	if len(args) != len(fields) { return fmt.Errorf("usage: limits <loss> <win> <minutes> <check>") }
+	for i, a := range args {
+	//
+		n, err := strconv.Atoi(a)
+		if err != nil || n < 0 { return fmt.Errorf("limits are 0 or more, not %q", a) }
+		*fields[i] = n
+	}
+	return SetLimits(l)
+}
+
+/*
+	Find a game by its number, its id (like "JacksOrBetter95"), or part of its name.
+	A name that matches a game exactly is used even if it's part of other names
+	("jacks or better" is 9/6 Jacks or Better, not 8/5).
+*/
+
+func find_game(name string) (int, error) {
+//
+	var found []int
+
+	if name == "" { return 0, fmt.Errorf("usage: game <name or number> (\"games\" lists them)") }
+	if g, err := strconv.Atoi(name); err == nil {
+	//
+		if g < 0 || g >= NUMGAMES { return 0, fmt.Errorf("no game number %d", g) }
+		return g, nil
+	}
+	for g := 0; g < NUMGAMES; g++ {
+	//
+		n := strings.ToLower(gamenames[g])
+		if n == name || strings.ToLower(gameids[g]) == name { return g, nil }
+		if strings.Contains(n, name) { found = append(found, g) }
+	}
+	switch len(found) {
+	//
+		case 0: return 0, fmt.Errorf("no game matches %q (\"games\" lists them)", name)
+		case 1: return found[0], nil
+	}
+	var names []string
+	for _, g := range found { names = append(names, gamenames[g]) }
+	return 0, fmt.Errorf("%q could be %s", name, strings.Join(names, ", "))
+}
@@ -0,0 +1,119 @@
+// Tests for the text commands
+
+package videopoker
+
+import (
+	"io"
+	"testing"
+	)
+
+/* Start a new session with a Recorder, without the text mode output */
+
+func start_test(t *testing.T) *Recorder {
+//
+	r := &Recorder{}
+	saved := Console
+	Console = io.Discard
+	t.Cleanup(func() { Console = saved; view = NoView{} })
+
+	use_game(t, JacksOrBetter)
+	Start(r)
+	new_session()
+	r.Reset()
+	return r
+}
+
+func TestCommandPlay(t *testing.T) {
+//
+	r := start_test(t)
+
+	if err := Command("bet 3"); err != nil { t.Fatal(err) }
+	if err := Command("deal"); err != nil { t.Fatal(err) }
+	if state != Draw { t.Fatalf("state after deal is %d", state) }
+	if score != INITCHIPS - 30 { t.Errorf("score after betting 30 is %d", score) }
+
+	if err := Command("hold 1 3 5"); err != nil { t.Fatal(err) }
+	if hold != [CARDS]int{ 1, 0, 1, 0, 1 } { t.Errorf("holds are %v", hold) }
+	if err := Command("hold 2"); err != nil { t.Fatal(err) }
+	if hold != [CARDS]int{ 0, 1, 0, 0, 0 } { t.Errorf("holds are %v", hold) }
+
+	held := hand[1]
+	if err := Command("draw"); err != nil { t.Fatal(err) }
+	if state != Deal { t.Errorf("state after draw is %d", state) }
+	if hand[1] != held { t.Errorf("held card changed from %s to %s", held, hand[1]) }
+	if len(r.Find("Win")) != 1 { t.Errorf("the hand wasn't scored: %v", r.Events) }
+}
+
+func TestCommandErrors(t *testing.T) {
+//
+	start_test(t)
+
+	bad := []string{
+		"", "shuffle", "draw", "hold 1", "bet", "bet 6", "bet x",
+		"game", "game deuces", "game jacks", "game 11", "new",
+	}
+	for _, c := range bad {
+	//
+		if Command(c) == nil { t.Errorf("%q: no error", c) }
+	}
+
+	Command("deal")
+	for _, c := range []string{ "deal", "bet 2", "hold 0", "hold 6", "game bonus poker" } {
+	//
+		if Command(c) == nil { t.Errorf("%q after dealing: no error", c) }
+	}
+}
+
+func TestFindGame(t *testing.T) {
+//
+	tests := map[string]int {
+		"jacks or better": JacksOrBetter,
+		"8/5": JacksOrBetter85,
+		"tens": TensOrBetter,
+		"double bonus bonus": DoubleBonusBonus,
+		"double bonus": DoubleBonus,
+		"american": AllAmerican,
+		"0": AllAmerican,
+		"10": JacksOrBetter65,
+	}
+	for name, want := range tests {
+	//
+		if g, err := find_game(name); err != nil || g != want {
+			t.Errorf("%q: got %d (%v), want %d", name, g, err, want)
+		}
+	}
+}
+
+func TestCommandGameAndQuit(t *testing.T) {
+//
+	start_test(t)
+
+	if err := Command("game bonus poker"); err != nil { t.Fatal(err) }
+	if game != BonusPoker { t.Errorf("game is %s", gamenames[game]) }
+	Command("hold")	// the new game starts with a deal
+	Command("draw")
+
+	if err := Command("quit"); err != nil { t.Fatal(err) }
+	if state != Over { t.Errorf("state after quit is %d", state) }
+	if Command("quit") == nil { t.Errorf("quit twice: no error") }
+	if err := Command("new"); err != nil { t.Fatal(err) }
+	if state != Deal || score != INITCHIPS { t.Errorf("new session: state %d, score %d", state, score) }
+}
+
+func TestCommandLimits(t *testing.T) {
+//
+	start_test(t)
+	t.Cleanup(func() { limits = LimitState{} })
+
+	for _, c := range []string{ "limits 1 2 3", "limits 1 2 3 x", "limits -1 0 0 0", "ok" } {
+	//
+		if Command(c) == nil { t.Errorf("%q: no error", c) }
+	}
+	if err := Command("limits 10 0 0 0"); err != nil || limits.LossLimit != 10 { t.Fatalf("limits: %v %+v", err, limits) }
+	limits.Net = -10	// as if 10 chips had been lost
+
+	if Command("deal") == nil || !LimitStopped() { t.Errorf("dealt at the loss limit") }
+	if Command("limits 0 0 0 0") == nil { t.Errorf("removed the limits while stopped") }
+	if err := Command("ok"); err != nil || LimitStopped() || state != Over { t.Errorf("ok: %v, state %d", err, state) }
+	if err := Command("limits 0 0 0 0"); err != nil || limits != (LimitState{}) { t.Errorf("removing the limits: %v", err) }
+}
@@ -0,0 +1,284 @@
+// Tests for the hand evaluator, recognize()
+
+package videopoker
+
+import (
+	"strings"
+	"testing"
+	)
+
+/* Put the cards in the hand, from text like "Ah 2c 3d 4s 5h", and evaluate it */
+
+func evaluate(t *testing.T, cards string) int {
+//
+	t.Helper()
+
+	names := strings.Fields(cards)
+	if len(names) != CARDS { t.Fatalf("%q: not %d cards", cards, CARDS) }
+	for i, name := range names {
+	//
+		hand[i] = find_card(t, name)
+	}
+	return recognize()
+}
+
+func find_card(t *testing.T, name string) card {
+//
+	t.Helper()
+
+	for _, c := range deck {
+	//
+		if c.String() == name { return c }
+	}
+	t.Fatalf("no card %q", name)
+	return card{}
+}
+
+/* Set the game, and put it back at the end of the test */
+
+func use_game(t *testing.T, g int) {
+//
+	saved := game
+	game = g
+	setgame(g)
+	t.Cleanup(func() { game = saved; setgame(saved) })
+}
+
+/*
+	The number of each kind of hand in all 2,598,960 five-card hands.
+	Pairs only count if they are jacks or better (4 ranks of 84,480 pairs each),
+	or tens or better in Tens or Better (5 ranks), and the rest are "Nothing".
+	The bonus games split up the 624 fours of a kind (48 of each rank).
+*/
+
+var jacks_counts [NUMHANDTYPES]int = [NUMHANDTYPES]int {
+	4,		/* royal flush */
+	36,		/* straight flush */
+	0, 0, 0, 0,	/* the bonus 4 of a kinds */
+	624,		/* 4 of a kind */
+	3744,		/* full house */
+	5108,		/* flush */
+	10200,		/* straight */
+	54912,		/* 3 of a kind */
+	123552,		/* two pair */
+	337920,		/* jacks or better */
+	2062860,	/* nothing */
+}
+
+var tens_counts [NUMHANDTYPES]int = [NUMHANDTYPES]int {
+	4, 36, 0, 0, 0, 0, 624, 3744, 5108, 10200, 54912, 123552,
+	422400,		/* tens or better */
+	1978380,	/* nothing */
+}
+
+var bonus_counts [NUMHANDTYPES]int = [NUMHANDTYPES]int {
+	4, 36, 0, 0,
+	48,		/* 4 aces */
+	144,		/* 4 2s, 3s or 4s */
+	432,		/* 4 5s to kings */
+	3744, 5108, 10200, 54912, 123552, 337920, 2062860,
+}
+
+var double_bonus_bonus_counts [NUMHANDTYPES]int = [NUMHANDTYPES]int {
+	4, 36,
+	12,		/* 4 aces with a 2, 3 or 4: 3 ranks of kicker, in 4 suits */
+	36,		/* 4 2s, 3s or 4s with an ace, 2, 3 or 4: 3 ranks of kicker for each */
+	36,		/* the other 4 aces */
+	108,		/* the other 4 2s, 3s or 4s */
+	432,
+	3744, 5108, 10200, 54912, 123552, 337920, 2062860,
+}
+
+/* Evaluate every five-card hand, and count each kind of hand */
+
+func count_all_hands() [NUMHANDTYPES]int {
+//
+	var counts [NUMHANDTYPES]int
+
+	for a := 0; a < CARDSINDECK; a++ {
+	for b := a+1; b < CARDSINDECK; b++ {
+	for c := b+1; c < CARDSINDECK; c++ {
+	for d := c+1; d < CARDSINDECK; d++ {
+	for e := d+1; e < CARDSINDECK; e++ {
+	//
+		hand = [CARDS]card{ deck[a], deck[b], deck[c], deck[d], deck[e] }
+		counts[recognize()]++
+	}}}}}
+	return counts
+}
+
+func TestAllHands(t *testing.T) {
+//
+	for g := 0; g < NUMGAMES; g++ {
+	//
+		// the other games are evaluated the same way as Jacks or Better, or Bonus Poker
+		if testing.Short() && g != JacksOrBetter && g != TensOrBetter && g != BonusPoker && g != DoubleBonusBonus { continue }
+
+		t.Run(gamenames[g], func(t *testing.T) {
+			use_game(t, g)
+
+			want := jacks_counts
+			switch g {
+			//
+				case TensOrBetter: want = tens_counts
+				case BonusPoker, DoubleBonus: want = bonus_counts
+				case DoubleBonusBonus: want = double_bonus_bonus_counts
+			}
+
+			counts := count_all_hands()
+			total := 0
+			for i := 0; i < NUMHANDTYPES; i++ {
+			//
+				total += counts[i]
+				if counts[i] != want[i] {
+					t.Errorf("%s: got %d, want %d", handname[i], counts[i], want[i])
+				}
+			}
+			if total != 2598960 { t.Errorf("evaluated %d hands, want 2598960", total) }
+		})
+	}
+}
+
+/* Hands that have been wrong before, or are easy to get wrong */
+
+func TestHands(t *testing.T) {
+//
+	use_game(t, JacksOrBetter)
+
+	tests := []struct {
+		cards string
+		want int
+	}{
+		{ "Ah Kh Qh Jh 10h", ROYAL },
+		{ "10s As Js Ks Qs", ROYAL },
+		{ "9d Kd Qd Jd 10d", STRFL },
+		{ "Ac 2c 3c 4c 5c", STRFL },		// the wheel: a straight flush, not a royal flush
+		{ "5s 4s 3s 2s As", STRFL },
+		{ "Ah 2c 3d 4s 5h", STR },		// ace-low straight
+		{ "3d Ah 5h 2c 4s", STR },
+		{ "10c Jd Qh Ks Ac", STR },		// ace-high straight
+		{ "Kc Ad 2h 3s 4c", NOTHING },		// straights don't wrap around
+		{ "Qc Kd Ah 2s 3c", NOTHING },
+		{ "2h 3h 4h 5h 7h", FLUSH },
+		{ "Ah Kh Qh Jh 9h", FLUSH },
+		{ "9c 9d 9h 9s 2c", FOURK },
+		{ "2c As Ad Ah Ac", FOURK },		// no bonus for aces in Jacks or Better
+		{ "3c 3d 3h 2s 2c", FULL },
+		{ "2c 2d 3h 3s 3c", FULL },
+		{ "7c 7d 7h Ks 2c", THREEK },
+		{ "Kc 7d 2h 7s 7c", THREEK },
+		{ "2c 2d 5h 5s Kc", TWOPAIR },
+		{ "2c 3d 3h 5s 5c", TWOPAIR },
+		{ "2c 2d 3h 5s 5c", TWOPAIR },
+		{ "Jc Jd 2h 5s 8c", PAIR },
+		{ "2c 5d Ah 9s Ac", PAIR },
+		{ "10c 10d 2h 5s 8c", NOTHING },	// tens aren't enough in Jacks or Better
+		{ "2c 2d 7h 5s 8c", NOTHING },
+		{ "2c 4d 7h 9s Jc", NOTHING },
+	}
+
+	for _, test := range tests {
+	//
+		if got := evaluate(t, test.cards); got != test.want {
+			t.Errorf("%s: got %s, want %s", test.cards, handname[got], handname[test.want])
+		}
+	}
+}
+
+func TestTensOrBetter(t *testing.T) {
+//
+	use_game(t, TensOrBetter)
+
+	if got := evaluate(t, "10c 10d 2h 5s 8c"); got != PAIR {
+		t.Errorf("pair of tens: got %s, want %s", handname[got], handname[PAIR])
+	}
+	if got := evaluate(t, "9c 9d 2h 5s 8c"); got != NOTHING {
+		t.Errorf("pair of nines: got %s, want %s", handname[got], handname[NOTHING])
+	}
+}
+
+/* The fours of a kind the bonus games pay more for, and their kickers */
+
+func TestBonusQuads(t *testing.T) {
+//
+	tests := []struct {
+		game int
+		cards string
+		want int
+	}{
+		{ BonusPoker, "As Ad Ah Ac 2c", FOURACES },
+		{ BonusPoker, "3s 3d 3h 3c Kc", FOUR24 },
+		{ BonusPoker, "4s 4d 4h 4c Ac", FOUR24 },
+		{ BonusPoker, "5s 5d 5h 5c Ac", FOURK },
+		{ DoubleBonus, "Ks Kd Kh Kc Ac", FOURK },
+		{ DoubleBonus, "Ac As Ad Ah 9c", FOURACES },
+		{ DoubleBonusBonus, "2c As Ad Ah Ac", FOURAKICK },	// the kicker can come first
+		{ DoubleBonusBonus, "As Ad Ah Ac 4c", FOURAKICK },
+		{ DoubleBonusBonus, "As Ad Ah Ac 5c", FOURACES },
+		{ DoubleBonusBonus, "2s 2d 2h 2c Ac", FOUR24KICK },
+		{ DoubleBonusBonus, "3s 3d 3h 3c 2c", FOUR24KICK },
+		{ DoubleBonusBonus, "4s 4d 4h 4c 5c", FOUR24 },
+		{ DoubleBonusBonus, "5s 5d 5h 5c Ac", FOURK },
+		{ AllAmerican, "As Ad Ah Ac 2c", FOURK },
+	}
+	for _, test := range tests {
+	//
+		use_game(t, test.game)
+		if got := evaluate(t, test.cards); got != test.want {
+			t.Errorf("%s in %s: got %s, want %s", test.cards, gamenames[test.game], handname[got], handname[test.want])
+		}
+	}
+}
+
+/*
+	None of the games have wild cards, so there is nothing to test for them.
+
+	The pay tables, for each chip bet with the maximum bet, are the published
+	full pay tables of each game.
+*/
+
+func TestPaytables(t *testing.T) {
+//
+	published := map[int][NUMHANDTYPES]int {
+	/*	                    royal, str fl, 4 aces+2-4, 4 2-4+A-4, 4 aces, 4 2-4, 4 kind, full, flush, straight, 3 kind, 2 pair, pair, nothing */
+		AllAmerican:      { 800, 200, 0, 0, 0, 0, 40, 8, 8, 8, 3, 1, 1, 0 },
+		TensOrBetter:     { 800, 50, 0, 0, 0, 0, 25, 6, 5, 4, 3, 2, 1, 0 },
+		BonusPoker:       { 800, 50, 0, 0, 80, 40, 25, 8, 5, 4, 3, 2, 1, 0 },
+		DoubleBonus:      { 800, 50, 0, 0, 160, 80, 50, 10, 7, 5, 3, 1, 1, 0 },
+		DoubleBonusBonus: { 800, 50, 400, 160, 160, 80, 50, 9, 6, 4, 3, 1, 1, 0 },
+		JacksOrBetter:    { 800, 50, 0, 0, 0, 0, 25, 9, 6, 4, 3, 2, 1, 0 },
+		JacksOrBetter95:  { 800, 50, 0, 0, 0, 0, 25, 9, 5, 4, 3, 2, 1, 0 },
+		JacksOrBetter86:  { 800, 50, 0, 0, 0, 0, 25, 8, 6, 4, 3, 2, 1, 0 },
+		JacksOrBetter85:  { 800, 50, 0, 0, 0, 0, 25, 8, 5, 4, 3, 2, 1, 0 },
+		JacksOrBetter75:  { 800, 50, 0, 0, 0, 0, 25, 7, 5, 4, 3, 2, 1, 0 },
+		JacksOrBetter65:  { 800, 50, 0, 0, 0, 0, 25, 6, 5, 4, 3, 2, 1, 0 },
+	}
+	if len(published) != NUMGAMES { t.Errorf("%d pay tables checked, want %d", len(published), NUMGAMES) }
+	for g, want := range published {
+	//
+		if paytables[g] != want { t.Errorf("%s pays %v, want %v", gamenames[g], paytables[g], want) }
+	}
+
+	/*
+		In every game, a better hand never pays less, and a bonus four of a kind
+		pays more than the others, and more again with the kicker
+	*/
+	ranked := []int{ ROYAL, STRFL, FOURK, FULL, FLUSH, STR, THREEK, TWOPAIR, PAIR, NOTHING }
+	for g := 0; g < NUMGAMES; g++ {
+	//
+		pays := &paytables[g]
+		for i := 1; i < len(ranked); i++ {
+		//
+			if pays[ranked[i]] > pays[ranked[i-1]] {
+				t.Errorf("%s: %s pays more than %s", gamenames[g], handname[ranked[i]], handname[ranked[i-1]])
+			}
+		}
+		for _, q := range [][2]int{ { FOURACES, FOURK }, { FOUR24, FOURK }, { FOURAKICK, FOURACES }, { FOUR24KICK, FOUR24 } } {
+		//
+			if pays[q[0]] != 0 && pays[q[0]] <= pays[q[1]] {
+				t.Errorf("%s: %s pays no more than %s", gamenames[g], handname[q[0]], handname[q[1]])
+			}
+		}
+		if pays[NOTHING] != 0 { t.Errorf("%s: Nothing pays %d", gamenames[g], pays[NOTHING]) }
+	}
+}
@@ -0,0 +1,183 @@
+// Limits for playing responsibly
+
+// The player can set a loss limit, a win goal, the longest time to play, and how often
+// to be shown the time played and the chips won or lost (a reality check).
+// They are checked after each draw and before each deal. When one is reached, play
+// stops: the View is told with Limit(), and no more hands are dealt until the player
+// acknowledges it with AcknowledgeLimit(), and the limits can't be raised or removed
+// until then. After a reality check, play goes on.
+// After the other limits, the session ends, and the counting starts again.
+//
+// The time and the chips are counted from when the limits are set, across sessions,
+// so starting a new session doesn't get around them. A front end keeps the counts
+// with GetLimits() and puts them back with RestoreLimits(), so reloading the web page
+// doesn't either.
+
+package videopoker
+
+import (
+	"errors"
+	"fmt"
+	"time"
+	)
+
+type Limits struct {
+	LossLimit int	`json:"lossLimit"`	// chips, or 0 for none (the same for the others)
+	WinGoal int	`json:"winGoal"`	// chips
+	MaxMinutes int	`json:"maxMinutes"`	// minutes of play
+	CheckMinutes int `json:"checkMinutes"`	// minutes between reality checks
+}
+
+type LimitState struct {
+	Limits
+	Started time.Time	`json:"started"`	// when the counting started
+	Checked time.Time	`json:"checked"`	// the last reality check
+	Net int			`json:"net"`		// chips won since then, or lost if it's negative
+	Stopped string		`json:"stopped"`	// "loss", "win", "time" or "check" while play is stopped, or ""
+}
+
+var limits LimitState
+
+/* The clock, which tests can change */
+
+var now func() time.Time = time.Now
+
+/*
+	Set the limits. The counting starts when the first one is set, and stops when there are none.
+	While play is stopped, the limits can only be made stricter, so clearing them doesn't get
+	around acknowledging it.
+*/
+
+func SetLimits(l Limits) error {
+//
+	if limits.Stopped != "" && looser(l, limits.Limits) {
+	//
+		return errors.New(tr("The limits can't be raised or removed until you click OK"))
+	}
+	if l == (Limits{}) {
+	//
+		limits = LimitState{}
+		view.Limit("")
+		return nil
+	}
+	if limits.Limits == (Limits{}) {
+	//
+		limits.Started, limits.Checked, limits.Net = now(), now(), 0
+	}
+	limits.Limits = l
+	return nil
+}
+
+/* True if any limit in l is higher than in old, or removed */
+
+func looser(l, old Limits) bool {
+//
+	new_limits := []int{ l.LossLimit, l.WinGoal, l.MaxMinutes, l.CheckMinutes }
+	for i, o := range []int{ old.LossLimit, old.WinGoal, old.MaxMinutes, old.CheckMinutes } {
+	//
+		if o > 0 && (new_limits[i] == 0 || new_limits[i] > o) { return true }
+	}
+	return false
+}
+
+func GetLimits() LimitState	{ return limits }
+func LimitStopped() bool	{ return limits.Stopped != "" }
+
+/* Put back the limits and the counts kept by GetLimits(), and stop play again if it was stopped */
+
+func RestoreLimits(l LimitState) {
+//
+	limits = l
+	if limits.Stopped != "" { view.Limit(limit_message()) }
+}
+
+/* The limit that has been reached, or "" */
+
+func limit_reached() string {
+//
+	played := now().Sub(limits.Started)
+	switch {
+	//
+		case limits.Limits == (Limits{}): return ""
+		case limits.LossLimit > 0 && -limits.Net >= limits.LossLimit: return "loss"
+		case limits.WinGoal > 0 && limits.Net >= limits.WinGoal: return "win"
+		case limits.MaxMinutes > 0 && played >= time.Duration(limits.MaxMinutes) * time.Minute: return "time"
+		case limits.CheckMinutes > 0 && now().Sub(limits.Checked) >= time.Duration(limits.CheckMinutes) * time.Minute: return "check"
+	}
+	return ""
+}
+
+/* Why play has stopped, the time played, and the chips won or lost */
+
+func limit_message() string {
+//
+	var reason, result string
+
+	switch limits.Stopped {
+	//
+		case "loss": reason = tr("You have reached your loss limit.")
+		case "win": reason = tr("You have reached your win goal.")
+		case "time": reason = tr("You have reached your time limit.")
+		default: reason = tr("Reality check.")
+	}
+	minutes := int(now().Sub(limits.Started) / time.Minute)
+	switch {
+	//
+		case limits.Net > 0: result = fmt.Sprintf(tr("You have won %s chips."), number(limits.Net))
+		case limits.Net < 0: result = fmt.Sprintf(tr("You have lost %s chips."), number(-limits.Net))
+		default: result = tr("You haven't won or lost any chips.")
+	}
+	return reason + " " + fmt.Sprintf(tr("You have played for %s minutes."), number(minutes)) + " " + result
+}
+
+/* Stop play if a limit has been reached. Returns true if play is stopped. */
+
+func check_limits() bool {
+//
+	if limits.Stopped == "" { limits.Stopped = limit_reached() }
+	if limits.Stopped == "" { return false }
+
+	msg := limit_message()
+	view.Message(msg)
+	view.Announce(msg)
+	view.Limit(msg)
+	return true
+}
+
+/* Count the chips bet and won, for the limits */
+
+func count_chips(n int) {
+//
+	if limits.Limits != (Limits{}) { limits.Net += n }
+}
+
+/* The first line of the summary, when a limit ends the session */
+
+var limit_endings map[string]string = map[string]string {
+	"loss": "You reached your loss limit",
+	"win": "You reached your win goal",
+	"time": "You reached your time limit",
+}
+
+/* The player has seen why play stopped, so it can go on */
+
+func AcknowledgeLimit() {
+//
+	stopped := limits.Stopped
+	if stopped == "" { return }
+	limits.Stopped = ""
+	limits.Checked = now()
+	view.Limit("")
+
+	if stopped == "check" {
+	//
+		view.Message(StateMessage())
+		return
+	}
+	if state != Over {
+	//
+		final_score()
+		end_session(tr(limit_endings[stopped]))
+	}
+	limits.Started, limits.Net = now(), 0
+}
@@ -0,0 +1,86 @@
+// Tests for the limits on play
+
+package videopoker
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+	)
+
+func TestLimits(t *testing.T) {
+//
+	r := start_test(t)
+	clock := time.Date(2026, 10, 19, 20, 0, 0, 0, time.UTC)
+	now = func() time.Time { return clock }
+	t.Cleanup(func() { now = time.Now; limits = LimitState{} })
+
+	SetLimits(Limits{ LossLimit: 100, CheckMinutes: 30 })
+	DealOrDraw()
+	if limits.Net != -10 { t.Errorf("after the deal, net %d", limits.Net) }
+	DealOrDraw()
+
+	/* a reality check, after half an hour */
+	clock = clock.Add(31 * time.Minute)
+	r.Reset()
+	DealOrDraw()
+	if state != Deal || !LimitStopped() || len(r.Find("Limit Reality check. You have played for 31 minutes.")) != 1 {
+		t.Fatalf("reality check: %v", r.Events)
+	}
+	if err := Command("deal"); err == nil || !strings.Contains(err.Error(), "Reality check") { t.Errorf("deal: %v", err) }
+
+	/* while play is stopped, the limits can be made stricter, but not raised or removed */
+	for _, l := range []Limits{ {}, { LossLimit: 100 }, { LossLimit: 200, CheckMinutes: 30 } } {
+	//
+		if err := SetLimits(l); err == nil || !LimitStopped() || limits.LossLimit != 100 || limits.CheckMinutes != 30 {
+			t.Errorf("%+v while stopped: %v", l, err)
+		}
+	}
+	if err := SetLimits(Limits{ LossLimit: 100, CheckMinutes: 30, MaxMinutes: 600 }); err != nil || limits.MaxMinutes != 600 {
+		t.Errorf("a stricter limit while stopped: %v", err)
+	}
+	if l := r.Find("Limit "); l[len(l)-1] == "Limit " { t.Errorf("the dialog was hidden: %v", l) }
+	AcknowledgeLimit()
+	if l := r.Find("Limit "); LimitStopped() || l[len(l)-1] != "Limit " { t.Errorf("acknowledged: %v", l) }
+	DealOrDraw()
+	if state != Draw { t.Fatalf("not dealt after the reality check") }
+	DealOrDraw()
+
+	/* the loss limit, which is kept when the page is reloaded */
+	limits.Net = -100
+	DealOrDraw()
+	if state != Deal || limits.Stopped != "loss" { t.Fatalf("loss limit: %+v", limits) }
+	b, _ := json.Marshal(GetLimits())
+	limits = LimitState{}
+	var kept LimitState
+	json.Unmarshal(b, &kept)
+	r.Reset()
+	RestoreLimits(kept)
+	if limits.Stopped != "loss" || len(r.Find("Limit You have reached your loss limit. You have played for 31 minutes. You have lost 100 chips.")) != 1 {
+		t.Errorf("restored: %+v %v", limits, r.Events)
+	}
+
+	/* the session ends when it's acknowledged, and the counting starts again */
+	AcknowledgeLimit()
+	if state != Over || len(r.Find("Summary You reached your loss limit")) != 1 || limits.Net != 0 || !limits.Started.Equal(clock) {
+		t.Errorf("after the loss limit: %+v %v", limits, r.Find("Summary"))
+	}
+	DealOrDraw()
+	DealOrDraw()
+	if state != Draw || limits.Net != -10 { t.Errorf("new session: %+v", limits) }
+
+	/* no limits */
+	SetLimits(Limits{})
+	clock = clock.Add(24 * time.Hour)
+	DealOrDraw()
+	DealOrDraw()
+	if LimitStopped() || limits.Net != 0 { t.Errorf("without limits: %+v", limits) }
+
+	/* sessions on a server start without any */
+	SetLimits(Limits{ MaxMinutes: 1 })
+	s := NewSession(NoView{}, JacksOrBetter, 1, "en")
+	s.Do(NoView{}, func() {
+		if GetLimits() != (LimitState{}) { t.Errorf("session limits: %+v", GetLimits()) }
+	})
+}
@@ -0,0 +1,434 @@
+// Translations of the player-facing text in Video Poker
+
+// The text is written in English in the rest of the program, and tr() looks up the
+// translation for the language being used. If there isn't one, the English is used.
+// Messages with numbers in them are format strings for fmt.Sprintf(), and are
+// translated before formatting. Use number() for scores and other numbers of chips,
+// so they are written the way the language writes numbers.
+//
+// To add a language, add its catalog to catalogs[] and its number format to numberformats[],
+// and add it to the language menu in index.html.
+
+package videopoker
+
+import (
+	"strconv"
+	"strings"
+	)
+
+// The language being used, like "en" or "de"
+
+var lang string = "en"
+
+// The languages, in the order the browser's preferred languages are checked
+
+var languages []string = []string { "en", "es", "de" }
+
+// How numbers are written: the separator between groups of three digits,
+// and the smallest number of digits that gets separators
+
+type numberformat struct {
+	sep string
+	min int
+}
+
+var numberformats map[string]numberformat = map[string]numberformat {
+	"en": { ",", 4 },	// 1,000
+	"es": { ".", 5 },	// 1000, but 10.000
+	"de": { ".", 4 },	// 1.000
+}
+
+// Write a number the way the language being used writes it
+
+func number(n int) string {
+	f, ok := numberformats[lang]
+	if !ok { f = numberformats["en"] }
+
+	s := strconv.Itoa(n)
+	sign := ""
+	if n < 0 { sign = "-"; s = s[1:] }
+	if len(s) < f.min { return sign + s }
+
+	var b strings.Builder
+	for i, d := range s {
+		if i > 0 && (len(s) - i) % 3 == 0 { b.WriteString(f.sep) }
+		b.WriteRune(d)
+	}
+	return sign + b.String()
+}
+
+// Translate a message into the language being used
+
+func tr(msg string) string {
+	if t, ok := catalogs[lang][msg]; ok { return t }
+	return msg
+}
+
+// Choose a language, from a list of language tags like "de-AT" in order of preference.
+// Returns "en" if none of the languages are wanted.
+
+func match_language(wanted []string) string {
+	for _, w := range wanted {
+		w = strings.ToLower(w)
+		for _, l := range languages {
+			if w == l || strings.HasPrefix(w, l + "-") { return l }
+		}
+	}
+	return "en"
+}
+
+// The translations, by language
+
+var catalogs map[string]map[string]string = map[string]map[string]string {
+	"es": {
+		// messages
+		"To continue, click on Deal New Hand": "Para continuar, haz clic en Repartir nueva mano",
+		"Click the cards to hold, then click on Draw Cards": "Haz clic en las cartas que quieras guardar y luego en Cambiar cartas",
+		"To play again, click on Start New Session": "Para volver a jugar, haz clic en Nueva sesión",
+		"Finish this hand before changing the game": "Termina esta mano antes de cambiar de juego",
+		"Can't reach the server": "No se puede conectar con el servidor",
+		"You quit with %s chips after playing %s hands": "Te retiras con %s fichas después de jugar %s manos",
+		"You quit the game": "Has dejado el juego",
+		"You ran out of chips after playing %s hands": "Te quedaste sin fichas después de jugar %s manos",
+		"You ran out of chips": "Te quedaste sin fichas",
+		"You are low on chips. Your bet has been reduced to %s": "Te quedan pocas fichas. Tu apuesta se ha reducido a %s",
+		"You don't have that many chips": "No tienes tantas fichas",
+		"Bet changed to %s chips": "Apuesta cambiada a %s fichas",
+		"Hands played: %s": "Manos jugadas: %s",
+		"Final chips: %s": "Fichas finales: %s",
+		"Range: %s - %s": "Rango: %s - %s",
+		"Rank: %s of %s": "Puesto: %s de %s",
+
+		// watching a game on the server
+		"Others can watch this game at:": "Otros pueden ver esta partida en:",
+		"%s watching": "%s mirando",
+		"Connecting to the game...": "Conectando con la partida...",
+		"The game isn't being shown any more": "La partida ya no se está mostrando",
+		"Best hold: %s, which pays back %s on average": "Mejor jugada: guardar %s, que devuelve %s de media",
+		"The best hold was %s, which paid back %s on average, and the player's %s": "La mejor jugada era guardar %s, que devolvía %s de media, y la del jugador %s",
+		"%s%%": "%s %%",
+		"none": "nada",
+		"Comment for the player": "Comentario para el jugador",
+		"Send": "Enviar",
+
+		// limits (see limits.go)
+		"You have reached your loss limit.": "Has llegado a tu límite de pérdidas.",
+		"You have reached your win goal.": "Has llegado a tu objetivo de ganancias.",
+		"You have reached your time limit.": "Has llegado a tu límite de tiempo.",
+		"Reality check.": "Control de realidad.",
+		"You have played for %s minutes.": "Has jugado durante %s minutos.",
+		"You have won %s chips.": "Has ganado %s fichas.",
+		"You have lost %s chips.": "Has perdido %s fichas.",
+		"You haven't won or lost any chips.": "No has ganado ni perdido fichas.",
+		"You reached your loss limit": "Llegaste a tu límite de pérdidas",
+		"You reached your win goal": "Llegaste a tu objetivo de ganancias",
+		"You reached your time limit": "Llegaste a tu límite de tiempo",
+		"OK": "Aceptar",
+		"Limits (leave empty for none):": "Límites (vacío para ninguno):",
+		"Loss limit (chips):": "Límite de pérdidas (fichas):",
+		"Win goal (chips):": "Objetivo de ganancias (fichas):",
+		"Time limit (minutes):": "Límite de tiempo (minutos):",
+		"Reality check every (minutes):": "Control de realidad cada (minutos):",
+		"The limits can't be raised or removed until you click OK": "Los límites no se pueden subir ni quitar hasta que pulses Aceptar",
+
+		// autoplay (see autoplay.go)
+		"Autoplay stopped after %s hands": "Juego automático detenido tras %s manos",
+		"Autoplay stopped after a royal flush": "Juego automático detenido tras una escalera real",
+		"Autoplay stopped after a win of %s chips": "Juego automático detenido tras ganar %s fichas",
+		"Autoplay stopped with fewer than %s chips": "Juego automático detenido con menos de %s fichas",
+		"Autoplay stopped with more than %s chips": "Juego automático detenido con más de %s fichas",
+		"Autoplay stopped at a limit": "Juego automático detenido en un límite",
+		"Autoplay stopped, since the session is over": "Juego automático detenido, porque la sesión ha terminado",
+		"Autoplay stopped": "Juego automático detenido",
+		"Autoplay": "Juego automático",
+		"Strategy:": "Estrategia:",
+		"Best": "La mejor",
+		"Simple": "Sencilla",
+		"Speed:": "Velocidad:",
+		"Stop after (hands):": "Parar tras (manos):",
+		"Stop on a royal flush": "Parar con una escalera real",
+		"Stop on a win of at least (chips):": "Parar al ganar al menos (fichas):",
+		"Stop below (chips):": "Parar por debajo de (fichas):",
+		"Stop above (chips):": "Parar por encima de (fichas):",
+		"Start": "Empezar",
+		"Stop": "Parar",
+
+		// buttons
+		"Deal New Hand": "Repartir nueva mano",
+		"Draw Cards": "Cambiar cartas",
+		"Start New Session": "Nueva sesión",
+
+		// games
+		"Tens or Better": "Dieces o Mejor",
+		"Bonus Poker": "Póker Bonus",
+		"Double Bonus": "Doble Bonus",
+		"Double Bonus Bonus": "Doble Bonus Bonus",
+		"Jacks or Better": "Jotas o Mejor",
+		"9/5 Jacks or Better": "9/5 Jotas o Mejor",
+		"8/6 Jacks or Better": "8/6 Jotas o Mejor",
+		"8/5 Jacks or Better": "8/5 Jotas o Mejor",
+		"7/5 Jacks or Better": "7/5 Jotas o Mejor",
+		"6/5 Jacks or Better": "6/5 Jotas o Mejor",
+
+		// hands
+		"Royal Flush": "Escalera Real",
+		"Straight Flush": "Escalera de Color",
+		"Four Aces with 2-4": "Póker de ases con 2-4",
+		"Four 2-4 with A-4": "Póker de 2-4 con A-4",
+		"Four Aces": "Póker de ases",
+		"Four 2-4": "Póker de 2-4",
+		"Four of a Kind": "Póker",
+		"Full House": "Full",
+		"Flush": "Color",
+		"Straight": "Escalera",
+		"Three of a Kind": "Trío",
+		"Two Pair": "Doble Pareja",
+		"Pair": "Pareja",
+		"Nothing": "Nada",
+
+		// cards, for screen readers
+		"%[1]s of %[2]s": "%[1]s de %[2]s",
+		"two": "dos", "three": "tres", "four": "cuatro", "five": "cinco", "six": "seis",
+		"seven": "siete", "eight": "ocho", "nine": "nueve", "ten": "diez",
+		"jack": "jota", "queen": "reina", "king": "rey", "ace": "as",
+		"clubs": "tréboles", "diamonds": "diamantes", "hearts": "corazones", "spades": "picas",
+		"no card": "sin carta",
+		"face down card": "carta boca abajo",
+		"%s, held": "%s, guardada",
+
+		// announcements, for screen readers
+		"Dealt %s": "Repartidas: %s",
+		"Card %d, %s, held": "Carta %d, %s, guardada",
+		"Card %d, %s, discarded": "Carta %d, %s, descartada",
+		"Drew %s. Nothing. Score %s": "Nuevas cartas: %s. Nada. Puntos: %s",
+		"Drew %s. %s. You win %s chips. Score %s": "Nuevas cartas: %s. %s. Ganas %s fichas. Puntos: %s",
+		"High contrast on": "Alto contraste activado",
+		"High contrast off": "Alto contraste desactivado",
+		"Sound on": "Sonido activado",
+		"Sound off": "Sonido desactivado",
+
+		// the terminal version
+		"HELD": "GUARDADA",
+		"Space J K L ; hold   Enter deal/draw   1-5 bet   Shift+A-I game   Q quit":
+			"Espacio J K L ; guardar   Intro repartir/cambiar   1-5 apostar   Mayús+A-I juego   Q salir",
+		"Enter new session   Q exit": "Intro nueva sesión   Q terminar",
+
+		// the page and the Settings panel
+		"Video Poker - ": "Video Póker - ",
+		"Score:": "Puntos:",
+		"Choose Game": "Elegir juego",
+		"Settings": "Ajustes",
+		"Keys:": "Teclas:",
+		"Choose a preset...": "Elige un esquema...",
+		"Home row (Space j k l ; to hold)": "Fila central (Espacio j k l ñ para guardar)",
+		"Casino (1-5 to hold, Enter to deal)": "Casino (1-5 para guardar, Intro para repartir)",
+		"Cards:": "Cartas:",
+		"Classic": "Clásicas",
+		"Standard (drawn, sharp at any size)": "Estándar (dibujadas, nítidas a cualquier tamaño)",
+		"Four-colour deck": "Baraja de cuatro colores",
+		"Large index (for small screens)": "Índices grandes (para pantallas pequeñas)",
+		"Animation:": "Animación:",
+		"Off": "No",
+		"Fast": "Rápida",
+		"Normal": "Normal",
+		"Slow": "Lenta",
+		"Volume:": "Volumen:",
+		"Sound: on": "Sonido: sí",
+		"Sound: off": "Sonido: no",
+		"Language:": "Idioma:",
+		"Automatic": "Automático",
+
+		// key bindings
+		"Hold card 1": "Guardar carta 1",
+		"Hold card 2": "Guardar carta 2",
+		"Hold card 3": "Guardar carta 3",
+		"Hold card 4": "Guardar carta 4",
+		"Hold card 5": "Guardar carta 5",
+		"Deal / Draw": "Repartir / Cambiar",
+		"Bet %s": "Apostar %s",
+		"Bet %s (maximum)": "Apostar %s (máximo)",
+		"Bet one more": "Apostar uno más",
+		"Quit": "Salir",
+		"High contrast on/off": "Alto contraste sí/no",
+		"Sound on/off": "Sonido sí/no",
+		"Type a key...": "Pulsa una tecla...",
+		"Change": "Cambiar",
+		"(none)": "(ninguna)",
+	},
+
+	"de": {
+		// messages
+		"To continue, click on Deal New Hand": "Zum Weiterspielen auf Neue Hand geben klicken",
+		"Click the cards to hold, then click on Draw Cards": "Karten zum Halten anklicken, dann auf Karten tauschen klicken",
+		"To play again, click on Start New Session": "Zum erneuten Spielen auf Neue Sitzung klicken",
+		"Finish this hand before changing the game": "Erst diese Hand zu Ende spielen, dann das Spiel wechseln",
+		"Can't reach the server": "Keine Verbindung zum Server",
+		"You quit with %s chips after playing %s hands": "Du hörst mit %s Chips nach %s Händen auf",
+		"You quit the game": "Du hast das Spiel beendet",
+		"You ran out of chips after playing %s hands": "Nach %s Händen hast du keine Chips mehr",
+		"You ran out of chips": "Du hast keine Chips mehr",
+		"You are low on chips. Your bet has been reduced to %s": "Du hast nur noch wenige Chips. Dein Einsatz wurde auf %s gesenkt",
+		"You don't have that many chips": "So viele Chips hast du nicht",
+		"Bet changed to %s chips": "Einsatz auf %s Chips geändert",
+		"Hands played: %s": "Gespielte Hände: %s",
+		"Final chips: %s": "Chips am Ende: %s",
+		"Range: %s - %s": "Spanne: %s - %s",
+		"Rank: %s of %s": "Platz %s von %s",
+
+		// watching a game on the server
+		"Others can watch this game at:": "Andere können dieses Spiel hier ansehen:",
+		"%s watching": "%s sehen zu",
+		"Connecting to the game...": "Verbindung zum Spiel wird hergestellt...",
+		"The game isn't being shown any more": "Das Spiel wird nicht mehr gezeigt",
+		"Best hold: %s, which pays back %s on average": "Am besten: %s halten, das bringt im Schnitt %s zurück",
+		"The best hold was %s, which paid back %s on average, and the player's %s": "Am besten war es, %s zu halten, das brachte im Schnitt %s zurück, und was der Spieler hielt %s",
+		"%s%%": "%s %%",
+		"none": "keine",
+		"Comment for the player": "Kommentar für den Spieler",
+		"Send": "Senden",
+
+		// limits (see limits.go)
+		"You have reached your loss limit.": "Du hast dein Verlustlimit erreicht.",
+		"You have reached your win goal.": "Du hast dein Gewinnziel erreicht.",
+		"You have reached your time limit.": "Du hast dein Zeitlimit erreicht.",
+		"Reality check.": "Realitätscheck.",
+		"You have played for %s minutes.": "Du spielst seit %s Minuten.",
+		"You have won %s chips.": "Du hast %s Chips gewonnen.",
+		"You have lost %s chips.": "Du hast %s Chips verloren.",
+		"You haven't won or lost any chips.": "Du hast keine Chips gewonnen oder verloren.",
+		"You reached your loss limit": "Du hast dein Verlustlimit erreicht",
+		"You reached your win goal": "Du hast dein Gewinnziel erreicht",
+		"You reached your time limit": "Du hast dein Zeitlimit erreicht",
+		"OK": "OK",
+		"Limits (leave empty for none):": "Limits (leer lassen für keins):",
+		"Loss limit (chips):": "Verlustlimit (Chips):",
+		"Win goal (chips):": "Gewinnziel (Chips):",
+		"Time limit (minutes):": "Zeitlimit (Minuten):",
+		"Reality check every (minutes):": "Realitätscheck alle (Minuten):",
+		"The limits can't be raised or removed until you click OK": "Die Limits können erst erhöht oder entfernt werden, wenn du auf OK klickst",
+
+		// autoplay (see autoplay.go)
+		"Autoplay stopped after %s hands": "Automatisches Spiel nach %s Händen angehalten",
+		"Autoplay stopped after a royal flush": "Automatisches Spiel nach einem Royal Flush angehalten",
+		"Autoplay stopped after a win of %s chips": "Automatisches Spiel nach einem Gewinn von %s Chips angehalten",
+		"Autoplay stopped with fewer than %s chips": "Automatisches Spiel mit weniger als %s Chips angehalten",
+		"Autoplay stopped with more than %s chips": "Automatisches Spiel mit mehr als %s Chips angehalten",
+		"Autoplay stopped at a limit": "Automatisches Spiel an einem Limit angehalten",
+		"Autoplay stopped, since the session is over": "Automatisches Spiel angehalten, da die Sitzung zu Ende ist",
+		"Autoplay stopped": "Automatisches Spiel angehalten",
+		"Autoplay": "Automatisch spielen",
+		"Strategy:": "Strategie:",
+		"Best": "Die beste",
+		"Simple": "Einfach",
+		"Speed:": "Tempo:",
+		"Stop after (hands):": "Anhalten nach (Hände):",
+		"Stop on a royal flush": "Bei einem Royal Flush anhalten",
+		"Stop on a win of at least (chips):": "Bei einem Gewinn von mindestens (Chips) anhalten:",
+		"Stop below (chips):": "Anhalten unter (Chips):",
+		"Stop above (chips):": "Anhalten über (Chips):",
+		"Start": "Start",
+		"Stop": "Stopp",
+
+		// buttons
+		"Deal New Hand": "Neue Hand geben",
+		"Draw Cards": "Karten tauschen",
+		"Start New Session": "Neue Sitzung",
+
+		// games
+		"Tens or Better": "Zehner oder besser",
+		"Bonus Poker": "Bonus-Poker",
+		"Double Bonus": "Doppel-Bonus",
+		"Double Bonus Bonus": "Doppel-Bonus-Bonus",
+		"Jacks or Better": "Buben oder besser",
+		"9/5 Jacks or Better": "9/5 Buben oder besser",
+		"8/6 Jacks or Better": "8/6 Buben oder besser",
+		"8/5 Jacks or Better": "8/5 Buben oder besser",
+		"7/5 Jacks or Better": "7/5 Buben oder besser",
+		"6/5 Jacks or Better": "6/5 Buben oder besser",
+
+		// hands
+		"Royal Flush": "Royal Flush",
+		"Straight Flush": "Straight Flush",
+		"Four Aces with 2-4": "Vier Asse mit 2-4",
+		"Four 2-4 with A-4": "Vierling 2-4 mit A-4",
+		"Four Aces": "Vier Asse",
+		"Four 2-4": "Vierling 2-4",
+		"Four of a Kind": "Vierling",
+		"Full House": "Full House",
+		"Flush": "Flush",
+		"Straight": "Straße",
+		"Three of a Kind": "Drilling",
+		"Two Pair": "Zwei Paare",
+		"Pair": "Paar",
+		"Nothing": "Nichts",
+
+		// cards, for screen readers
+		"%[1]s of %[2]s": "%[2]s %[1]s",
+		"two": "Zwei", "three": "Drei", "four": "Vier", "five": "Fünf", "six": "Sechs",
+		"seven": "Sieben", "eight": "Acht", "nine": "Neun", "ten": "Zehn",
+		"jack": "Bube", "queen": "Dame", "king": "König", "ace": "Ass",
+		"clubs": "Kreuz", "diamonds": "Karo", "hearts": "Herz", "spades": "Pik",
+		"no card": "keine Karte",
+		"face down card": "verdeckte Karte",
+		"%s, held": "%s, gehalten",
+
+		// announcements, for screen readers
+		"Dealt %s": "Ausgeteilt: %s",
+		"Card %d, %s, held": "Karte %d, %s, gehalten",
+		"Card %d, %s, discarded": "Karte %d, %s, abgelegt",
+		"Drew %s. Nothing. Score %s": "Neue Karten: %s. Nichts. Punkte: %s",
+		"Drew %s. %s. You win %s chips. Score %s": "Neue Karten: %s. %s. Du gewinnst %s Chips. Punkte: %s",
+		"High contrast on": "Hoher Kontrast an",
+		"High contrast off": "Hoher Kontrast aus",
+		"Sound on": "Ton an",
+		"Sound off": "Ton aus",
+
+		// the terminal version
+		"HELD": "GEHALTEN",
+		"Space J K L ; hold   Enter deal/draw   1-5 bet   Shift+A-I game   Q quit":
+			"Leertaste J K L ; halten   Enter geben/ziehen   1-5 setzen   Umschalt+A-I Spiel   Q aufhören",
+		"Enter new session   Q exit": "Enter neue Sitzung   Q beenden",
+
+		// the page and the Settings panel
+		"Video Poker - ": "Video-Poker - ",
+		"Score:": "Punkte:",
+		"Choose Game": "Spiel wählen",
+		"Settings": "Einstellungen",
+		"Keys:": "Tasten:",
+		"Choose a preset...": "Vorlage wählen...",
+		"Home row (Space j k l ; to hold)": "Grundreihe (Leertaste j k l ö zum Halten)",
+		"Casino (1-5 to hold, Enter to deal)": "Kasino (1-5 zum Halten, Enter zum Geben)",
+		"Cards:": "Karten:",
+		"Classic": "Klassisch",
+		"Standard (drawn, sharp at any size)": "Standard (gezeichnet, in jeder Größe scharf)",
+		"Four-colour deck": "Vierfarbiges Blatt",
+		"Large index (for small screens)": "Große Indizes (für kleine Bildschirme)",
+		"Animation:": "Animation:",
+		"Off": "Aus",
+		"Fast": "Schnell",
+		"Normal": "Normal",
+		"Slow": "Langsam",
+		"Volume:": "Lautstärke:",
+		"Sound: on": "Ton: an",
+		"Sound: off": "Ton: aus",
+		"Language:": "Sprache:",
+		"Automatic": "Automatisch",
+
+		// key bindings
+		"Hold card 1": "Karte 1 halten",
+		"Hold card 2": "Karte 2 halten",
+		"Hold card 3": "Karte 3 halten",
+		"Hold card 4": "Karte 4 halten",
+		"Hold card 5": "Karte 5 halten",
+		"Deal / Draw": "Geben / Tauschen",
+		"Bet %s": "%s setzen",
+		"Bet %s (maximum)": "%s setzen (Maximum)",
+		"Bet one more": "Einsatz erhöhen",
+		"Quit": "Beenden",
+		"High contrast on/off": "Hoher Kontrast an/aus",
+		"Sound on/off": "Ton an/aus",
+		"Type a key...": "Taste drücken...",
+		"Change": "Ändern",
+		"(none)": "(keine)",
+	},
+}
@@ -0,0 +1,147 @@
+// More than one game at a time, for servers
+
+// The engine keeps the game in package variables, which is fine for one player
+// in a browser or a terminal. A server plays a game for each of its players,
+// so each one is kept in a Session, and is put into the package variables
+// while it's being played. Only one Session is played at a time.
+
+package videopoker
+
+import (
+	"math/rand"
+	"sync"
+	)
+
+type Session struct {
+	state int
+	hands int
+	hand [CARDS]card
+	hold [CARDS]int
+	deck [CARDSINDECK]card
+	score, score_low, score_high int
+	minbet, bet, betmultiplier int
+	game int
+	paytable [NUMHANDTYPES]int
+	randomgen *rand.Rand
+	fixed_deals bool
+	deal_seed int64
+	limits LimitState
+	lang string
+	view View
+}
+
+/* Locked while a Session is in the package variables */
+
+var engine sync.Mutex
+
+/* Copy the package variables into s, and back */
+
+func (s *Session) save() {
+//
+	s.state = state
+	s.hands = hands
+	s.hand = hand
+	s.hold = hold
+	s.deck = deck
+	s.score, s.score_low, s.score_high = score, score_low, score_high
+	s.minbet, s.bet, s.betmultiplier = minbet, bet, betmultiplier
+	s.game = game
+	s.paytable = paytable
+	s.randomgen = randomgen
+	s.fixed_deals, s.deal_seed = fixed_deals, deal_seed
+	s.limits = limits
+	s.lang = lang
+	s.view = view
+}
+
+func (s *Session) load() {
+//
+	state = s.state
+	hands = s.hands
+	hand = s.hand
+	hold = s.hold
+	deck = s.deck
+	score, score_low, score_high = s.score, s.score_low, s.score_high
+	minbet, bet, betmultiplier = s.minbet, s.bet, s.betmultiplier
+	game = s.game
+	paytable = s.paytable
+	randomgen = s.randomgen
+	fixed_deals, deal_seed = s.fixed_deals, s.deal_seed
+	limits = s.limits
+	lang = s.lang
+	view = s.view
+}
+
+/*
+	Start a session of game g, in language (like "de"), showing it with v.
+	The cards are shuffled with seed, so two sessions with the same seed
+	are dealt the same cards.
+*/
+
+func NewSession(v View, g int, seed int64, language string) *Session {
+//
+	var saved Session
+
+	if g < 0 || g >= NUMGAMES { g = JacksOrBetter }
+
+	s := &Session{}
+	engine.Lock()
+	defer engine.Unlock()
+	saved.save()
+
+	view = v
+	randomgen = rand.New(rand.NewSource(seed))
+	fixed_deals = false
+	limits = LimitState{}
+	lang = match_language([]string{ language })
+	game = g
+	setgame(game)
+	for i := 0; i < CARDS; i++ { hand[i] = transparent_card; hold[i] = 0 }
+	for i := 0; i < CARDSINDECK; i++ { deck[i].gone = 0 }
+	view.Game(game)
+	new_session()
+	view.Paytable(NOTHING)
+
+	s.save()
+	saved.load()
+	return s
+}
+
+/* Play s, showing it with v: the functions in api.go called by f act on s */
+
+func (s *Session) Do(v View, f func()) {
+//
+	var saved Session
+
+	engine.Lock()
+	defer engine.Unlock()
+	saved.save()
+	s.load()
+	view = v
+
+	f()
+
+	s.save()
+	saved.load()
+}
+
+/*
+	Deal each hand of s from seed and the hand's number, for tournaments.
+	The cards that replace the discards come from the same shuffle, in order,
+	so all of the sessions with the same seed get the same deals and the same
+	draw cards, whichever cards their players hold.
+*/
+
+func (s *Session) FixDeals(seed int64) {
+//
+	s.fixed_deals = true
+	s.deal_seed = seed
+}
+
+/* Start s with this many chips, instead of INITCHIPS */
+
+func (s *Session) SetChips(chips int) {
+//
+	s.score, s.score_low, s.score_high = chips, chips, chips
+	if s.view != nil { s.view.Score(chips) }
+}
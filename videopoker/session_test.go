@@ -0,0 +1,116 @@
+// Tests for playing more than one game at a time, and for Script
+
+package videopoker
+
+import (
+	"io"
+	"testing"
+	)
+
+func TestSessions(t *testing.T) {
+//
+	saved := Console
+	Console = io.Discard
+	t.Cleanup(func() { Console = saved })
+
+	a := NewSession(NoView{}, JacksOrBetter, 1, "en")
+	b := NewSession(NoView{}, BonusPoker, 1, "de")
+	c := NewSession(NoView{}, JacksOrBetter, 2, "en")
+
+	var ha, hb, hc [CARDS]Card
+	a.Do(NoView{}, func() { Bet(5); DealOrDraw(); ha = Hand() })
+	b.Do(NoView{}, func() { DealOrDraw(); hb = Hand() })
+	c.Do(NoView{}, func() { DealOrDraw(); hc = Hand() })
+
+	/* the same seed deals the same cards */
+	if ha != hb { t.Errorf("same seed, different hands: %v and %v", ha, hb) }
+	if ha == hc { t.Errorf("different seeds, same hand: %v", ha) }
+
+	/* each session keeps its own game */
+	a.Do(NoView{}, func() {
+		if s := GetState(); s.Score != INITCHIPS - 50 || s.Variant != "JacksOrBetter" || s.Phase != "draw" {
+			t.Errorf("session a: %+v", s)
+		}
+	})
+	b.Do(NoView{}, func() {
+		if s := GetState(); s.Score != INITCHIPS - 10 || s.Variant != "BonusPoker" || Language() != "de" {
+			t.Errorf("session b: %+v in %s", s, Language())
+		}
+	})
+}
+
+/* What a Script plays on another View is the same as what the engine did */
+
+func TestScript(t *testing.T) {
+//
+	r := start_test(t)
+	script := &Script{}
+	view = script
+
+	Bet(3)
+	DealOrDraw()
+	ToggleHold(0)
+	DealOrDraw()
+	Quit()
+
+	played := &Recorder{}
+	script.Play(played)
+	view = r
+	if len(played.Events) == 0 { t.Fatal("nothing was played") }
+	if len(played.Events) != len(script.Calls) {
+		t.Fatalf("played %d of %d calls", len(played.Events), len(script.Calls))
+	}
+
+	/* and the state can be put into another game with Load() */
+	s := GetState()
+	new_session()
+	if err := Load(s); err != nil { t.Fatal(err) }
+	if got := GetState(); got.Score != s.Score || got.Hand[4] != s.Hand[4] || got.Phase != "over" {
+		t.Errorf("loaded %+v, want %+v", got, s)
+	}
+}
+
+/* Sessions with the same fixed deals get the same cards, whatever they hold */
+
+func TestFixDeals(t *testing.T) {
+//
+	saved := Console
+	Console = io.Discard
+	t.Cleanup(func() { Console = saved })
+
+	a := NewSession(NoView{}, JacksOrBetter, 1, "en")
+	b := NewSession(NoView{}, JacksOrBetter, 2, "en")
+	c := NewSession(NoView{}, JacksOrBetter, 1, "en")
+	a.FixDeals(42)
+	b.FixDeals(42)
+	c.FixDeals(43)
+	b.SetChips(500)
+
+	for n := 0; n < 20; n++ {
+	//
+		var da, db, dc, ha, hb [CARDS]Card
+		a.Do(NoView{}, func() { DealOrDraw(); da = Hand(); DealOrDraw(); ha = Hand() })	// holds nothing
+		b.Do(NoView{}, func() {
+			DealOrDraw()
+			db = Hand()
+			ToggleHold(0)
+			ToggleHold(2)
+			DealOrDraw()
+			hb = Hand()
+		})
+		c.Do(NoView{}, func() { DealOrDraw(); dc = Hand(); DealOrDraw() })
+
+		if da != db { t.Fatalf("hand %d: different deals: %v and %v", n+1, da, db) }
+		if da == dc { t.Errorf("hand %d: different seeds, same deal: %v", n+1, da) }
+
+		/* b's two new cards are the first two that replaced a's */
+		if hb[0] != db[0] || hb[2] != db[2] || hb[1] != ha[0] || hb[3] != ha[1] || hb[4] != ha[2] {
+			t.Fatalf("hand %d: a drew %v, b held 1 and 3 and drew %v", n+1, ha, hb)
+		}
+	}
+	b.Do(NoView{}, func() {
+		if s := GetState(); s.HandsPlayed != 20 || s.PeakScore < 500 || s.Score > s.PeakScore {
+			t.Errorf("session b: %+v", s)
+		}
+	})
+}
@@ -0,0 +1,289 @@
+// Finding the best cards to hold
+
+// For each of the 32 ways to hold the cards, every draw from the 47 cards
+// left in the deck is tried, and the one that pays the most on average is best.
+// That's 2,598,960 hands for each deal, so the hands are evaluated with
+// hand_type() below, which is faster than recognize() and gets the same answers
+// (see strategy_test.go).
+
+package videopoker
+
+/* The type of a hand (ROYAL to NOTHING) in game g, from its ranks and suits */
+
+func hand_type(ranks *[CARDS]int, suits *[CARDS]int, g int) int {
+//
+	var count [ACE+1]int
+	var bits, pairs, high_pairs, threes, fours int
+	var four_rank, kicker int
+
+	flush := true
+	min := JACK
+	if g == TensOrBetter { min = TEN }
+
+	for i := 0; i < CARDS; i++ {
+	//
+		count[ranks[i]]++
+		bits |= 1 << uint(ranks[i])
+		if suits[i] != suits[0] { flush = false }
+	}
+	for r := TWO; r <= ACE; r++ {
+	//
+		switch count[r] {
+		//
+			case 1: kicker = r
+			case 2:
+				pairs++
+				if r >= min { high_pairs++ }
+			case 3: threes++
+			case 4: fours++; four_rank = r
+		}
+	}
+
+	/* five different ranks in a row, or A 2 3 4 5 */
+	straight := false
+	low := 0
+	if pairs == 0 && threes == 0 && fours == 0 {
+	//
+		for low = TWO; bits & (1 << uint(low)) == 0; low++ {}
+		straight = bits == 0x1f << uint(low) || bits == 1 << ACE | 0xf << TWO
+	}
+
+	switch {
+	//
+		case straight && flush && low == TEN: return ROYAL
+		case straight && flush: return STRFL
+		case fours == 1: return quads(four_rank, kicker, g)
+		case threes == 1 && pairs == 1: return FULL
+		case flush: return FLUSH
+		case straight: return STR
+		case threes == 1: return THREEK
+		case pairs == 2: return TWOPAIR
+		case high_pairs == 1: return PAIR
+	}
+	return NOTHING
+}
+
+/*
+	The average that holding the cards in held pays in game g, for each chip bet,
+	like 0.5 if half of the bet comes back
+*/
+
+func HoldValue(h [CARDS]Card, g int, held [CARDS]bool) float64 {
+//
+	return hold_value(h, g, held, nil)
+}
+
+/* HoldValue(), calling pause (unless it's nil) after every 65,536 hands */
+
+func hold_value(h [CARDS]Card, g int, held [CARDS]bool, pause func()) float64 {
+//
+	var rest [CARDSINDECK - CARDS]card
+	var ranks, suits [CARDS]int
+	var total, draws int
+
+	n := 0
+	for _, c := range all_cards {
+	//
+		in_hand := false
+		for _, d := range h {
+		//
+			if c.index == d.index && c.suit == d.suit { in_hand = true }
+		}
+		if !in_hand && n < len(rest) { rest[n] = c; n++ }
+	}
+
+	kept := 0
+	for i := 0; i < CARDS; i++ {
+	//
+		if held[i] { ranks[kept], suits[kept] = h[i].index, h[i].suit; kept++ }
+	}
+	pays := &paytables[g]
+
+	/* every way of drawing the rest of the hand from the deck */
+	var draw func(next, from int)
+	draw = func(next, from int) {
+		if next == CARDS {
+		//
+			total += pays[hand_type(&ranks, &suits, g)]
+			draws++
+			if pause != nil && draws & 0xffff == 0 { pause() }
+			return
+		}
+		for i := from; i < n; i++ {
+		//
+			ranks[next], suits[next] = rest[i].index, rest[i].suit
+			draw(next+1, i+1)
+		}
+	}
+	draw(kept, 0)
+
+	return float64(total) / float64(draws)
+}
+
+/* The best cards to hold in game g, and what they pay on average for each chip bet */
+
+func BestHold(h [CARDS]Card, g int) ([CARDS]bool, float64) {
+//
+	return best_hold(h, g, nil)
+}
+
+/* BestHold(), calling pause (unless it's nil) now and then */
+
+func best_hold(h [CARDS]Card, g int, pause func()) ([CARDS]bool, float64) {
+//
+	var best [CARDS]bool
+	var best_value float64 = -1
+
+	/* if two are as good, the first is kept, starting with holding them all */
+	for m := 1<<CARDS - 1; m >= 0; m-- {
+	//
+		var held [CARDS]bool
+		for i := 0; i < CARDS; i++ { held[i] = m & (1 << uint(i)) != 0 }
+		if v := hold_value(h, g, held, pause); v > best_value + 1e-9 {
+		//
+			best, best_value = held, v
+		}
+	}
+	return best, best_value
+}
+
+/*
+	A simple strategy, which is easy to remember, and pays almost as much
+	as the best one in the Jacks or Better games. The first of these that
+	the hand has is held:
+
+		a straight flush or a royal flush
+		4 cards to a royal flush
+		a straight, flush, full house or four of a kind
+		three of a kind, or two pair
+		4 cards to a straight flush
+		a pair that pays
+		3 cards to a royal flush
+		4 cards to a flush
+		a pair that doesn't pay
+		4 cards in a row, which can make a straight at either end
+		2 cards of the same suit that would pay as a pair
+		1 or 2 cards that would pay as a pair (the lowest 2, if there are more)
+
+	and if it has none of them, all five cards are drawn.
+*/
+
+func SimpleHold(h [CARDS]Card, g int) [CARDS]bool {
+//
+	var ranks, suits [CARDS]int
+	var count [ACE+1]int
+	var all [CARDS]bool
+
+	min := JACK
+	if g == TensOrBetter { min = TEN }
+	for i := 0; i < CARDS; i++ {
+	//
+		ranks[i], suits[i] = h[i].index, h[i].suit
+		count[ranks[i]]++
+		all[i] = true
+	}
+	high := func(i int) bool { return ranks[i] >= min }
+	royal := func(i int) bool { return ranks[i] >= TEN }
+	every := func(i int) bool { return true }
+
+	/* the cards for which want is true */
+	where := func(want func(i int) bool) ([CARDS]bool, int) {
+		var held [CARDS]bool
+		n := 0
+		for i := 0; i < CARDS; i++ {
+		//
+			if want(i) { held[i] = true; n++ }
+		}
+		return held, n
+	}
+
+	/* the most cards of one suit for which want is true */
+	suited := func(want func(i int) bool) ([CARDS]bool, int) {
+		var best [CARDS]bool
+		most := 0
+		for s := CLUBS; s < NUMSUITS; s++ {
+		//
+			if held, n := where(func(i int) bool { return suits[i] == s && want(i) }); n > most { best, most = held, n }
+		}
+		return best, most
+	}
+
+	/*
+		The four cards other than card skip, if they make a straight with
+		one more card, or with open, if they are in a row and can make a straight
+		at either end (not A 2 3 4 or J Q K A). With flush, they have to be suited too.
+	*/
+	four_straight := func(flush, open bool) ([CARDS]bool, bool) {
+		for skip := 0; skip < CARDS; skip++ {
+		//
+			held, _ := where(func(i int) bool { return i != skip })
+			bits, low_bits := 0, 0	// the ranks, with aces high and with aces low
+			suit := suits[(skip+1) % CARDS]
+			same_suit := true
+			for i := 0; i < CARDS; i++ {
+			//
+				if i == skip { continue }
+				bits |= 1 << uint(ranks[i])
+				if ranks[i] == ACE { low_bits |= 1 } else { low_bits |= 1 << uint(ranks[i]) }
+				if suits[i] != suit { same_suit = false }
+			}
+			if flush && !same_suit { continue }
+			for low := 0; low + 4 <= ACE; low++ {
+			//
+				switch {
+				//
+					case open && low >= TWO && low + 3 < ACE && bits == 0xf << uint(low): return held, true
+					case !open && (count_bits(bits & (0x1f << uint(low))) == 4 || count_bits(low_bits & (0x1f << uint(low))) == 4):
+						return held, true
+				}
+			}
+		}
+		return [CARDS]bool{}, false
+	}
+
+	/* the 2 lowest of the cards */
+	lowest_two := func(held [CARDS]bool, n int) [CARDS]bool {
+		for ; n > 2; n-- {
+		//
+			top := -1
+			for i := 0; i < CARDS; i++ {
+			//
+				if held[i] && (top < 0 || ranks[i] > ranks[top]) { top = i }
+			}
+			held[top] = false
+		}
+		return held
+	}
+
+	made := hand_type(&ranks, &suits, g)
+	if made == ROYAL || made == STRFL { return all }
+	if held, n := suited(royal); n == 4 { return held }
+	switch made {
+	//
+		case FOURAKICK, FOUR24KICK, FOURACES, FOUR24, FOURK, FULL, FLUSH, STR: return all
+		case THREEK:
+			held, _ := where(func(i int) bool { return count[ranks[i]] == 3 })
+			return held
+		case TWOPAIR:
+			held, _ := where(func(i int) bool { return count[ranks[i]] == 2 })
+			return held
+	}
+	if held, ok := four_straight(true, false); ok { return held }
+	if held, n := where(func(i int) bool { return count[ranks[i]] == 2 && high(i) }); n == 2 { return held }
+	if held, n := suited(royal); n == 3 { return held }
+	if held, n := suited(every); n == 4 { return held }
+	if held, n := where(func(i int) bool { return count[ranks[i]] == 2 }); n == 2 { return held }
+	if held, ok := four_straight(false, true); ok { return held }
+	if held, n := suited(high); n >= 2 { return lowest_two(held, n) }
+	held, n := where(high)
+	return lowest_two(held, n)
+}
+
+/* The number of bits that are 1 */
+
+func count_bits(b int) int {
+//
+	n := 0
+	for ; b != 0; b &= b - 1 { n++ }
+	return n
+}
@@ -0,0 +1,142 @@
+// Tests for finding the best hold
+
+package videopoker
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+	)
+
+/* hand_type() agrees with recognize() about every hand */
+
+func TestHandType(t *testing.T) {
+//
+	for _, g := range []int{ JacksOrBetter, TensOrBetter, DoubleBonusBonus } {
+	//
+		use_game(t, g)
+		var ranks, suits [CARDS]int
+
+		for a := 0; a < CARDSINDECK; a++ {
+		for b := a+1; b < CARDSINDECK; b++ {
+		for c := b+1; c < CARDSINDECK; c++ {
+		for d := c+1; d < CARDSINDECK; d++ {
+		for e := d+1; e < CARDSINDECK; e++ {
+		//
+			hand = [CARDS]card{ deck[a], deck[b], deck[c], deck[d], deck[e] }
+			for i := 0; i < CARDS; i++ { ranks[i], suits[i] = hand[i].index, hand[i].suit }
+			if want, got := recognize(), hand_type(&ranks, &suits, g); got != want {
+				t.Fatalf("%s in %s: %s, want %s", handtext(), gamenames[g], handname[got], handname[want])
+			}
+		}}}}}
+		if testing.Short() { break }
+	}
+}
+
+func hold_text(h [CARDS]Card, held [CARDS]bool) string {
+//
+	var s []string
+	for i := 0; i < CARDS; i++ {
+	//
+		if held[i] { s = append(s, h[i].String()) }
+	}
+	return strings.Join(s, " ")
+}
+
+func TestBestHold(t *testing.T) {
+//
+	tests := []struct {
+		game int
+		cards string
+		hold string
+	}{
+		{ JacksOrBetter, "Ah Kh Qh Jh 10h", "Ah Kh Qh Jh 10h" },	// a royal flush
+		{ JacksOrBetter, "Ah Kh Qh Jh 2h", "Ah Kh Qh Jh" },	// 4 to a royal is better than a flush
+		{ JacksOrBetter, "Jc Jd 5s 8h 2c", "Jc Jd" },		// a high pair
+		{ JacksOrBetter, "10c 10d 5s 8h 2c", "10c 10d" },	// a low pair
+		{ JacksOrBetter, "2c 2d 2s 9h 9c", "2c 2d 2s 9h 9c" },	// a full house
+		{ JacksOrBetter, "3c 5d 7s 9h Kc", "Kc" },
+		{ TensOrBetter,  "10c 10d 5s 8h 2c", "10c 10d" },
+		{ DoubleBonusBonus, "As Ad Ah Ac 9c", "As Ad Ah Ac" },	// drawing for a 2, 3 or 4 pays more
+	}
+	for _, test := range tests {
+	//
+		var h [CARDS]Card
+		for i, name := range strings.Fields(test.cards) { h[i] = find_card(t, name) }
+		start := time.Now()
+		held, value := BestHold(h, test.game)
+		if got := hold_text(h, held); got != test.hold {
+			t.Errorf("%s in %s: held %q (%.4f), want %q (%.4f)", test.cards, gamenames[test.game], got, value,
+				test.hold, HoldValue(h, test.game, [CARDS]bool{ true, true, true, true, true }))
+		}
+		if d := time.Since(start); d > 5 * time.Second { t.Errorf("%s took %v", test.cards, d) }
+	}
+
+	/* what a pair of jacks is worth in 9/6 Jacks or Better, from the strategy tables */
+	var h [CARDS]Card
+	for i, name := range strings.Fields("Jc Jd 5s 8h 2c") { h[i] = find_card(t, name) }
+	if v := HoldValue(h, JacksOrBetter, [CARDS]bool{ true, true }); v < 1.5365 || v > 1.5366 {
+		t.Errorf("holding a pair of jacks pays %.6f, want 1.5365", v)
+	}
+}
+
+func TestSimpleHold(t *testing.T) {
+//
+	tests := []struct {
+		game int
+		cards string
+		hold string
+	}{
+		{ JacksOrBetter, "9h 10h Jh Qh Kh", "9h 10h Jh Qh Kh" },	// a straight flush
+		{ JacksOrBetter, "Ah Kh Qh Jh 2h", "Ah Kh Qh Jh" },	// 4 to a royal is better than a flush
+		{ JacksOrBetter, "4c 5d 6s 7h 8c", "4c 5d 6s 7h 8c" },	// a straight
+		{ JacksOrBetter, "7c 7d 7s Ah 2c", "7c 7d 7s" },
+		{ JacksOrBetter, "7c 7d 2s Ah 2c", "7c 7d 2s 2c" },
+		{ JacksOrBetter, "5h 6h 7h 9h 9c", "5h 6h 7h 9h" },	// 4 to a straight flush is better than a low pair
+		{ JacksOrBetter, "Ah 2h 3h 4h 4c", "Ah 2h 3h 4h" },	// aces are low too
+		{ JacksOrBetter, "Qc Qd 5h 6h 7h", "Qc Qd" },		// a high pair
+		{ JacksOrBetter, "Qh Kh Ah 2h 9c", "Qh Kh Ah" },	// 3 to a royal is better than 4 to a flush
+		{ JacksOrBetter, "2h 5h 8h Jh 9c", "2h 5h 8h Jh" },	// 4 to a flush
+		{ JacksOrBetter, "4c 4d 5h 6s 7h", "4c 4d" },		// a low pair is better than 4 to a straight
+		{ JacksOrBetter, "5c 6d 7h 8s Kh", "5c 6d 7h 8s" },	// 4 to an open straight
+		{ JacksOrBetter, "Jc Qd Kh As 3h", "Jc Qd" },		// J Q K A isn't open
+		{ JacksOrBetter, "Jh Qh 2c 5d 8s", "Jh Qh" },
+		{ JacksOrBetter, "Jc Qd Kh 5s 3h", "Jc Qd" },		// the 2 lowest high cards
+		{ JacksOrBetter, "2c 4d 6h 8s 10h", "" },
+		{ TensOrBetter,  "10c 4d 6h 8s 2h", "10c" },
+	}
+	for _, test := range tests {
+	//
+		var h [CARDS]Card
+		for i, name := range strings.Fields(test.cards) { h[i] = find_card(t, name) }
+		if got := hold_text(h, SimpleHold(h, test.game)); got != test.hold {
+			t.Errorf("%s in %s: held %q, want %q", test.cards, gamenames[test.game], got, test.hold)
+		}
+	}
+}
+
+/* The hold values can be found while sessions are being played (go test -race) */
+
+func TestHoldValueInSessions(t *testing.T) {
+//
+	saved := Console
+	Console = io.Discard
+	t.Cleanup(func() { Console = saved })
+
+	var h [CARDS]Card
+	for i, name := range strings.Fields("Jc Jd 5s 8h 2c") {
+	//
+		c, ok := ParseCard(name)
+		if !ok { t.Fatalf("no card %s", name) }
+		h[i] = c
+	}
+	s := NewSession(NoView{}, JacksOrBetter, 1, "en")
+	done := make(chan bool)
+	go func() {
+		for i := 0; i < 20; i++ { s.Do(NoView{}, func() { DealOrDraw() }) }
+		done <- true
+	}()
+	for i := 0; i < 20; i++ { HoldValue(h, JacksOrBetter, [CARDS]bool{ true, true, true, true }) }
+	<-done
+}
@@ -0,0 +1,153 @@
+// A View for terminals
+
+// The Terminal keeps what the engine has told it, and Draw() shows all of it
+// on the screen at once, using ANSI escape sequences for moving the cursor
+// and for colours. The front end calls Draw() after each thing the player does,
+// so the screen is drawn once for each move, instead of once for each change.
+
+package videopoker
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	)
+
+type Terminal struct {
+	Out io.Writer
+	ANSI bool		// false for plain text, for terminals without escape sequences
+	Help string		// shown at the bottom of the screen, for the keys
+
+	message string
+	handname string
+	hand [CARDS]Card
+	held [CARDS]bool
+	facedown [CARDS]bool
+	score int
+	win int
+	summary []string
+}
+
+func NewTerminal(out io.Writer, ansi bool) *Terminal {
+//
+	t := &Terminal{ Out: out, ANSI: ansi, score: INITCHIPS, win: NOTHING }
+	for i := 0; i < CARDS; i++ { t.hand[i] = transparent_card }
+	return t
+}
+
+func (t *Terminal) Message(msg string)		{ t.message = msg }
+func (t *Terminal) Game(g int)			{}
+func (t *Terminal) HandName(name string)	{ t.handname = name }
+func (t *Terminal) Hold(n int, held bool)	{ t.held[n] = held }
+func (t *Terminal) FaceDown(n int)		{ t.facedown[n] = true }
+func (t *Terminal) Score(score int)		{ t.score = score }
+func (t *Terminal) Button(state int)		{}
+func (t *Terminal) Paytable(win int)		{ t.win = win }
+func (t *Terminal) Summary(lines []string)	{ t.summary = lines }
+func (t *Terminal) Announce(msg string)		{}
+func (t *Terminal) Sound(name string)		{}
+func (t *Terminal) Win(handtype int)		{}
+func (t *Terminal) Limit(msg string)		{}	// the message says why play stopped
+
+func (t *Terminal) Hand(hand [CARDS]Card) {
+//
+	t.hand = hand
+	t.facedown = [CARDS]bool{}
+}
+
+/* ANSI escape sequences */
+
+const (
+	ansi_reset = "\x1b[0m"
+	ansi_bold = "\x1b[1m"
+	ansi_reverse = "\x1b[7m"
+	ansi_red = "\x1b[31m"
+	ansi_green = "\x1b[32m"
+	ansi_home = "\x1b[H"
+	ansi_clear_line = "\x1b[K"
+	ansi_clear_rest = "\x1b[J"
+)
+
+/* Suit symbols for the cards */
+
+var terminal_suits [NUMSUITS]string = [NUMSUITS]string { "♣", "♦", "♥", "♠" }
+
+/* Put an escape sequence around s, if they're being used */
+
+func (t *Terminal) style(s, esc string) string {
+//
+	if !t.ANSI { return s }
+	return esc + s + ansi_reset
+}
+
+/* A card, like "[10♥]" */
+
+func (t *Terminal) card(n int) string {
+//
+	c := t.hand[n]
+	switch {
+	//
+		case t.facedown[n]: return "[###]"
+		case c.Blank(): return "[   ]"
+	}
+	s := fmt.Sprintf("%3s", strings.TrimSpace(c.sym) + terminal_suits[c.suit])
+	if c.suit == HEARTS || c.suit == DIAMONDS { s = t.style(s, ansi_red) }
+	return "[" + s + "]"
+}
+
+/* Draw the whole screen */
+
+func (t *Terminal) Draw() {
+//
+	var lines []string
+
+	lines = append(lines, t.style(tr("Video Poker - ") + tr(gamenames[game]), ansi_bold), "")
+
+	/* the pay table, with the column for the bet and the row for a win highlighted */
+	for i := ROYAL; i < NOTHING; i++ {
+	//
+		if paytable[i] == 0 { continue }	/* not in this game */
+		row := fmt.Sprintf("%-20s", tr(handname[i]))
+		for m := 1; m <= 5; m++ {
+		//
+			pay := fmt.Sprintf("%7s", number(paytable[i] * m * minbet))
+			if m == betmultiplier { pay = t.style(pay, ansi_reverse) }
+			row += pay
+		}
+		if i == t.win { row = t.style(row, ansi_bold + ansi_green) + "  <" }
+		lines = append(lines, "  " + row)
+	}
+	lines = append(lines, "")
+
+	/* the cards, their numbers, and which are held */
+	var cards, numbers, held string
+	for i := 0; i < CARDS; i++ {
+	//
+		cards += "  " + t.card(i) + "   "
+		numbers += fmt.Sprintf("    %d     ", i+1)
+		if t.held[i] { held += " " + t.style(fmt.Sprintf("%-9s", tr("HELD")), ansi_green) } else { held += "          " }
+	}
+	lines = append(lines, cards, numbers, held, "")
+
+	lines = append(lines, "  " + t.message)
+	lines = append(lines, fmt.Sprintf("  %-20s %s %s", t.handname, tr("Score:"), t.style(number(t.score), ansi_bold)))
+	if len(t.summary) == 0 {
+	//
+		/* the statistics for the session so far */
+		lines = append(lines, "  " + fmt.Sprintf(tr("Hands played: %s"), number(hands)) + "    " +
+			fmt.Sprintf(tr("Range: %s - %s"), number(score_low), number(score_high)))
+	}
+	for _, s := range t.summary { lines = append(lines, "  " + s) }
+	if t.Help != "" { lines = append(lines, "", "  " + t.Help) }
+
+	if t.ANSI {
+	//
+		fmt.Fprint(t.Out, ansi_home)
+		for _, l := range lines { fmt.Fprint(t.Out, l + ansi_clear_line + "\r\n") }
+		fmt.Fprint(t.Out, ansi_clear_rest)
+	} else {
+	//
+		for _, l := range lines { fmt.Fprintln(t.Out, strings.TrimRight(l, " ")) }
+		fmt.Fprintln(t.Out)
+	}
+}
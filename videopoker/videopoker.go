@@ -1,9 +1,11 @@
 // Video Poker Game for WebAssembly/Go
 //
 // version 1.0
-package main
+package videopoker
 
-// This is the game engine for the Video Poker web app
+// This is the game engine for the Video Poker web app.
+// It doesn't know anything about web pages, so it can also be used
+// by other front ends, like the one for terminals, and tested with go test.
 
 // Note to Reader:
 // There are some things in this file that are non-idiomatic Go code.
@@ -13,19 +15,33 @@ package main
 // comments, and I put empty // comments at the beginnings of blocks, to preserve
 // the formatting of the original code.
 
-// Functions that start with GUI_ (usually GUI_update_<thing>) are in main.go
-// They are for modifying elements in the GUI interface to WebAssembly
+// The engine shows what's happening by calling the methods of a View (see view.go),
+// which the front end supplies when it calls Start(). The front end controls the game
+// with the functions in api.go.
 
-// fmt.Printf() prints to the browser's Developer Tools debug console,
-// allowing the game to be played in text mode.
+// printf() prints to Console, which is the browser's Developer Tools debug console
+// in the web app, allowing the game to be played in text mode.
+
+// Text shown to the player is in English, and is translated with tr()
+// (see messages.go). Numbers of chips are written with number().
 
 import (
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
 	"time"
 	)
 
+/* Where the text mode output goes. Set it to io.Discard to turn it off. */
+
+var Console io.Writer = os.Stdout
+
+func printf(format string, a ...interface{}) {
+//
+	fmt.Fprintf(Console, format, a...)
+}
+
 /* Replacement for C library random() function */
 
 var randomgen *rand.Rand
@@ -40,6 +56,20 @@ func random() int {
 	return randomgen.Int()
 }
 
+/*
+	For tournaments (see Session.FixDeals), each hand is shuffled with its own seed,
+	made from the tournament's seed and the number of the hand, so it doesn't
+	depend on how many cards were drawn in the hands before it
+*/
+
+var fixed_deals bool
+var deal_seed int64
+
+func hand_seed(seed int64, n int) int64 {
+//
+	return seed ^ (int64(n) * -7046029254386353131)	// 0x9E3779B97F4A7C15, to spread the bits
+}
+
 /* ASCII key codes */
 
 // NOTE: iota is not used here because the numbers must match the keys */
@@ -181,6 +211,13 @@ const (
 
 func key_action(key byte) {
 //
+	// After the session has ended, the only thing to do is start a new one
+	if state == Over {
+	//
+		if key == key_Return { new_session() }
+		return
+	}
+
         switch key {
                 case key_ctrlJ: fallthrough
                 case key_ctrlK:
@@ -263,6 +300,15 @@ var suitname [NUMSUITS]string = [NUMSUITS]string {
 	"s",
 	}
 
+/* suit names, for reading the cards out loud (see cardname()) */
+
+var suitfullname [NUMSUITS]string = [NUMSUITS]string {
+	"clubs",
+	"diamonds",
+	"hearts",
+	"spades",
+	}
+
 /* Card values. NOTE: They are one lower than number on card faces */
 
 const (
@@ -281,6 +327,14 @@ const (
 	ACE  /* needed for recognizing Ace-low straight (Ace, 2, 3, 4, 5) */
 )
 
+/* card value names, indexed by card value (the first is unused) */
+
+var rankname [ACE+1]string = [ACE+1]string {
+	"",
+	"two", "three", "four", "five", "six", "seven", "eight", "nine", "ten",
+	"jack", "queen", "king", "ace",
+}
+
 /* the card type, for holding infomation about the deck of cards */
 
 type card struct
@@ -353,22 +407,54 @@ var deck [CARDSINDECK]card = [CARDSINDECK]card {
 	{ ACE,   " A", "01-spades.png", SPADES, 0 },
 }
 
+/*
+	The same 52 cards, which are never changed. Sessions swap deck in and out
+	(see session.go), so code that runs outside a session, like finding the
+	best hold on the server, uses these instead.
+*/
+
+var all_cards [CARDSINDECK]card = deck
+
 // transparent card, used at start
 var transparent_card = card{ ACE, " A", "nocard.png", HEARTS, 0 }
 
+/* The name of a card, like "ten of hearts", for screen readers, in the player's language */
+
+func cardname(c card) string {
+//
+	if c.uc == transparent_card.uc { return tr("no card") }
+	return fmt.Sprintf(tr("%[1]s of %[2]s"), tr(rankname[c.index]), tr(suitfullname[c.suit]))
+}
+
+/* All of the cards in the hand, left to right, separated by commas */
+
+func handtext() string {
+//
+	var s string
+
+	for i := 0; i < CARDS; i++ {
+	//
+		if i > 0 { s += ", " }
+		s += cardname(hand[i])
+	}
+	return s
+}
+
 /* state of the deal/draw button */
 
 const (
 	Deal = iota
 	Draw
+	Over	// the session has ended, waiting for a new one to be started
 )
 
-/* state is either Deal or Draw, depending on what the deal/draw button's current function is */
+/* state is Deal, Draw or Over, depending on what the deal/draw button's current function is */
 
 var state int = Deal
 
 var msg_deal string = "To continue, click on Deal New Hand"
 var msg_draw string = "Click the cards to hold, then click on Draw Cards"
+var msg_over string = "To play again, click on Start New Session"
 
 /* The hand. It holds five cards. */
 
@@ -427,6 +513,22 @@ const (
 
 var game int = JacksOrBetter
 
+/* names of the games for programs, like the constants above (see GetState() in api.go) */
+
+var gameids [NUMGAMES]string = [NUMGAMES]string {
+	"AllAmerican",
+	"TensOrBetter",
+	"BonusPoker",
+	"DoubleBonus",
+	"DoubleBonusBonus",
+	"JacksOrBetter",
+	"JacksOrBetter95",
+	"JacksOrBetter86",
+	"JacksOrBetter85",
+	"JacksOrBetter75",
+	"JacksOrBetter65",
+}
+
 var gamenames [NUMGAMES]string = [NUMGAMES]string {
 	"All American",
 	"Tens or Better",
@@ -496,6 +598,29 @@ func four() bool {
 	return false
 }
 
+/*
+	Four of a kind of rank, with kicker as the fifth card, in game g.
+	The bonus games pay more for four aces and four 2s, 3s or 4s,
+	and Double Bonus Bonus more again when the kicker is low (or an ace).
+*/
+
+func quads(rank, kicker, g int) int {
+//
+	low := rank >= TWO && rank <= FOUR
+
+	switch g {
+	//
+		case DoubleBonusBonus:
+			if rank == ACE && kicker >= TWO && kicker <= FOUR { return FOURAKICK }
+			if low && (kicker == ACE || kicker >= TWO && kicker <= FOUR) { return FOUR24KICK }
+			fallthrough
+		case BonusPoker, DoubleBonus:
+			if rank == ACE { return FOURACES }
+			if low { return FOUR24 }
+	}
+	return FOURK
+}
+
 /*
 	Full house:
 	3 of a kind and a pair
@@ -606,7 +731,12 @@ func recognize() int {
 
 	if st && fl && shand[0].index == TEN { return ROYAL }
 	if st && fl { return STRFL }
-	if four() { return FOURK }
+	if four() {
+	//
+		kicker := shand[0].index
+		if kicker == shand[2].index { kicker = shand[4].index }
+		return quads(shand[2].index, kicker, game)
+	}
 	if full() { return FULL }
 	if fl { return FLUSH }
 	if st { return STR }
@@ -627,6 +757,10 @@ func recognize() int {
 const (
 	ROYAL = iota
 	STRFL
+	FOURAKICK	/* four aces with a 2, 3 or 4 */
+	FOUR24KICK	/* four 2s, 3s or 4s with an ace, 2, 3 or 4 */
+	FOURACES
+	FOUR24		/* four 2s, 3s or 4s */
 	FOURK
 	FULL
 	FLUSH
@@ -642,6 +776,10 @@ const (
 var paytable [NUMHANDTYPES]int = [NUMHANDTYPES]int {
 	800,	/* royal flush: 800 */
 	50,	/* straight flush: 50 */
+	0,	/* the bonus 4 of a kinds are only in the bonus games */
+	0,
+	0,
+	0,
 	25,	/* 4 of a kind: 25 */
 	9,	/* full house: 9 */
 	6,	/* flush: 6 */
@@ -652,67 +790,98 @@ var paytable [NUMHANDTYPES]int = [NUMHANDTYPES]int {
 	0,	/* nothing */
 }
 
+/*
+	The pay tables of all of the games, indexed by game, for each chip bet
+	with the maximum bet. They are the full pay tables casinos use.
+	The columns are in the same order as paytable[] above, and a hand that
+	pays 0 (other than nothing) isn't in that game (see quads()).
+	Double Bonus Bonus is the game casinos call Double Double Bonus.
+*/
+
+var paytables [NUMGAMES][NUMHANDTYPES]int = [NUMGAMES][NUMHANDTYPES]int {
+/*	royal, str fl, 4 aces+2-4, 4 2-4+A-4, 4 aces, 4 2-4, 4 kind, full, flush, straight, 3 kind, 2 pair, pair, nothing */
+	{ 800, 200, 0, 0, 0, 0, 40, 8, 8, 8, 3, 1, 1, 0 },		/* All American */
+	{ 800, 50, 0, 0, 0, 0, 25, 6, 5, 4, 3, 2, 1, 0 },		/* Tens or Better */
+	{ 800, 50, 0, 0, 80, 40, 25, 8, 5, 4, 3, 2, 1, 0 },		/* 8/5 Bonus Poker */
+	{ 800, 50, 0, 0, 160, 80, 50, 10, 7, 5, 3, 1, 1, 0 },		/* 10/7 Double Bonus */
+	{ 800, 50, 400, 160, 160, 80, 50, 9, 6, 4, 3, 1, 1, 0 },	/* 9/6 Double Bonus Bonus */
+	{ 800, 50, 0, 0, 0, 0, 25, 9, 6, 4, 3, 2, 1, 0 },		/* 9/6 Jacks or Better (default) */
+	{ 800, 50, 0, 0, 0, 0, 25, 9, 5, 4, 3, 2, 1, 0 },		/* 9/5 Jacks or Better */
+	{ 800, 50, 0, 0, 0, 0, 25, 8, 6, 4, 3, 2, 1, 0 },		/* 8/6 Jacks or Better */
+	{ 800, 50, 0, 0, 0, 0, 25, 8, 5, 4, 3, 2, 1, 0 },		/* 8/5 Jacks or Better */
+	{ 800, 50, 0, 0, 0, 0, 25, 7, 5, 4, 3, 2, 1, 0 },		/* 7/5 Jacks or Better */
+	{ 800, 50, 0, 0, 0, 0, 25, 6, 5, 4, 3, 2, 1, 0 },		/* 6/5 Jacks or Better */
+}
+
 var handname [NUMHANDTYPES]string = [NUMHANDTYPES]string {
-	"Royal Flush    ",
-	"Straight Flush ",
-	"Four of a Kind ",
-	"Full House     ",
-	"Flush          ",
-	"Straight       ",
+	"Royal Flush",
+	"Straight Flush",
+	"Four Aces with 2-4",
+	"Four 2-4 with A-4",
+	"Four Aces",
+	"Four 2-4",
+	"Four of a Kind",
+	"Full House",
+	"Flush",
+	"Straight",
 	"Three of a Kind",
-	"Two Pair       ",
-	"Pair           ",
-	"Nothing        ",
+	"Two Pair",
+	"Pair",
+	"Nothing",
 }
 
 const INVALID = 100	/* higher than any valid card index */
 
 func changegame(g int) {
 //
+	if g < 0 || g >= NUMGAMES { return }
+
+	/* Don't allow changing the game in the middle of a hand */
+	if state == Draw {
+	//
+		view.Message(tr("Finish this hand before changing the game"))
+		return
+	}
+
         /* End this game */
-        final_score()
+	if state != Over { final_score() }
 
         /* Start new game */
         game = g
-        score = INITCHIPS
-        score_low = INITCHIPS
-        score_high = INITCHIPS
-        bet = INITMINBET
-        minbet = INITMINBET
-        betmultiplier = 1
         setgame(game)
-	GUI_update_gamename(gamenames[g])
-        starting_banner()
+	view.Game(g)
+	new_session()
+	view.Paytable(NOTHING)
         deal()
 }
 
+/* Reset the chips and statistics, and get ready to deal the first hand */
+
+func new_session() {
+//
+	score = INITCHIPS
+	score_low = INITCHIPS
+	score_high = INITCHIPS
+	hands = 0
+	bet = INITMINBET
+	minbet = INITMINBET
+	betmultiplier = 1
+
+	view.Summary(nil)
+	view.Score(score)
+	view.HandName(" ")
+	starting_banner()
+
+	state = Deal
+	view.Button(state)
+	view.Message(tr(msg_deal))
+}
+
+/* Set the pay table for the game */
+
 func setgame(game int) {
 //
-	switch game {
-	//
-		case JacksOrBetter95:
-			paytable[FLUSH] = 5
-		case JacksOrBetter86:
-			paytable[FULL] = 8
-		case JacksOrBetter85:
-			paytable[FULL] = 8
-			paytable[FLUSH] = 5
-		case JacksOrBetter75:
-			paytable[FULL] = 7
-			paytable[FLUSH] = 5
-		case JacksOrBetter65:
-			paytable[FULL] = 6
-			paytable[FLUSH] = 5
-		case AllAmerican:
-			paytable[FULL] = 8
-			paytable[FLUSH] = 8
-			paytable[STR] = 8
-			paytable[PAIR] = 1
-		case TensOrBetter:
-			/* pay table same as JacksOrBetter65 */
-			paytable[FULL] = 6
-			paytable[FLUSH] = 5
-	}
+	paytable = paytables[game]
 }
 
 /* set minimum bet to 1 chip */
@@ -732,11 +901,11 @@ func showheld() {
 
         for i = 0; i < CARDS; i++ {
 	//
-		GUI_update_hold(i)
+		view.Hold(i, hold[i] != 0)
                 if(hold[i] != 0) { pm = " +" } else { pm = "  " }
-                fmt.Printf("%s  ", pm)
+                printf("%s  ", pm)
         }
-        fmt.Printf("\n")
+        printf("\n")
 }
 
 /* Display the hand */
@@ -745,16 +914,16 @@ func showhand() {
 //
 	var i int
 
-	GUI_update_hand()	// update card images on web page
+	view.Hand(hand)	// update card images on web page
 
 	/* First line: show cards */
 
 	for i = 0; i < CARDS; i++ {
 	//
-		fmt.Printf("%s%s ", hand[i].sym, suitname[hand[i].suit])
+		printf("%s%s ", hand[i].sym, suitname[hand[i].suit])
 	}
 
-	fmt.Printf("\n")
+	printf("\n")
 
 	/* Second line: show which cards are held */
 
@@ -766,14 +935,22 @@ func deal() {
 	var i int
 	var crd int
 
+	/* not if the player has reached a limit (see limits.go) */
+	if check_limits() { return }
+
 	/* initialize deck */
 	for i = 0; i < CARDSINDECK; i++ { deck[i].gone = 0 }
 
 	/* initialize hold[] */
 	clear_holds()
 
+	/* the cards are turned over as they are dealt */
+	for i = 0; i < CARDS; i++ { view.FaceDown(i) }
+	view.Sound("deal")
+
 	score -= bet
-	GUI_update_score(score)
+	count_chips(-bet)
+	view.Score(score)
 
 	/* To test Ace-low straights, uncomment this section and the test: label below */
 /*
@@ -807,6 +984,8 @@ func deal() {
 	goto test
 */
 
+	if fixed_deals { randomgen = rand.New(rand.NewSource(hand_seed(deal_seed, hands))) }
+
 	for i = 0; i < CARDS; i++ {
 	//
 		/* find a card not already dealt */
@@ -824,34 +1003,53 @@ func deal() {
 // test:
 	// enter Draw state
 
-	GUI_update_handname(" ")
+	view.HandName(" ")
+	view.Paytable(NOTHING)
 	showhand()
+	view.Announce(fmt.Sprintf(tr("Dealt %s"), handtext()))
 	state = Draw
-	GUI_update_button()
-	GUI_update_message(msg_draw)
+	view.Button(state)
+	view.Message(tr(msg_draw))
 }
 
 func starting_banner() {
 //
 	/* Before starting play, print the name of the game in green */
 
-	fmt.Printf("\n%s\n\n",gamenames[game])
+	printf("\n%s\n\n",tr(gamenames[game]))
 }
 
 func final_score() {
 //
 	var msg string;
 
-	msg = fmt.Sprintf("You quit with %d chips after playing %d hands",score,hands)
-	GUI_update_message(msg)
-	fmt.Printf("%s\n",msg)
-	fmt.Printf("Range: %d - %d\n", score_low, score_high)
+	msg = fmt.Sprintf(tr("You quit with %s chips after playing %s hands"),number(score),number(hands))
+	view.Message(msg)
+	printf("%s\n",msg)
+	printf(tr("Range: %s - %s") + "\n", number(score_low), number(score_high))
+}
+
+/*
+	End the session and show the summary.
+	The program keeps running, so a new session can be
+	started without reloading the page.
+*/
+
+func end_session(msg string) {
+//
+	state = Over
+	view.Summary([]string{ msg, fmt.Sprintf(tr("Hands played: %s"), number(hands)),
+		fmt.Sprintf(tr("Final chips: %s"), number(score)),
+		fmt.Sprintf(tr("Range: %s - %s"), number(score_low), number(score_high)) })
+	view.Button(state)
+	view.Message(tr(msg_over))
 }
 
 func do_quit() {
 //
+	// quitting in the middle of a hand forfeits the bet
         final_score()
-        os.Exit(0)
+	end_session(tr("You quit the game"))
 }
 
 func do_bet(digit byte) {
@@ -859,20 +1057,22 @@ func do_bet(digit byte) {
 	var s string
 
 	// allow changing bet only before new hand is dealed
-	if state == Draw { return }
+	if state != Deal { return }
 
-        betmultiplier = int(digit) - key_0
-        b := betmultiplier * minbet
+        m := int(digit) - key_0
+        b := m * minbet
 	if b > score {
 	//
-		s = fmt.Sprintf("You don't have that many chips")
+		s = tr("You don't have that many chips")
 	} else {
 	//
+		betmultiplier = m
 		bet = b
-		s = fmt.Sprintf("Bet changed to %d chips",bet)
+		s = fmt.Sprintf(tr("Bet changed to %s chips"),number(bet))
+		view.Paytable(NOTHING)
 	}
-	GUI_update_message(s)
-	fmt.Printf("%s\n",s)
+	view.Message(s)
+	printf("%s\n",s)
         showhand()
 }
 
@@ -881,16 +1081,23 @@ func clear_holds() {
 	for i := 0; i < CARDS; i++ {
 	//
 		hold[i] = 0
-		GUI_update_hold(i)
+		view.Hold(i, hold[i] != 0)
 	}
 }
 
 func toggle_hold(i int) {
 //
-	if state == Deal { return }
+	if state != Draw { return }
         /* flip bit to hold/discard it */
         hold[i] ^= 1
-	GUI_update_hold(i)
+	view.Hold(i, hold[i] != 0)
+	if hold[i] != 0 {
+		view.Sound("hold")
+		view.Announce(fmt.Sprintf(tr("Card %d, %s, held"), i+1, cardname(hand[i])))
+	} else {
+		view.Sound("unhold")
+		view.Announce(fmt.Sprintf(tr("Card %d, %s, discarded"), i+1, cardname(hand[i])))
+	}
         /* redisplay hand */
         showhand()
 }
@@ -901,6 +1108,8 @@ func draw() {
         var crd int
 	var msg string
 
+        view.Sound("draw")
+
         /* replace cards not held */
 
         for i = 0; i < CARDS; i++ {
@@ -915,6 +1124,7 @@ func draw() {
 
                         deck[crd].gone = 1
                         hand[i] = deck[crd]
+			view.FaceDown(i)
                 }
         }
 
@@ -927,11 +1137,23 @@ func draw() {
         i = recognize()
 
         score += paytable[i] * bet
+	count_chips(paytable[i] * bet)
+
+        printf("%-15s  ",tr(handname[i]))
+	view.HandName(tr(handname[i]))
+        printf("%d\n\n",score)
+	view.Score(score)
+
+	view.Win(i)
+	if i == NOTHING {
+		view.Announce(fmt.Sprintf(tr("Drew %s. Nothing. Score %s"), handtext(), number(score)))
+	} else {
+		view.Announce(fmt.Sprintf(tr("Drew %s. %s. You win %s chips. Score %s"),
+			handtext(), tr(handname[i]), number(paytable[i] * bet), number(score)))
+	}
 
-        fmt.Printf("%s  ",handname[i])
-	GUI_update_handname(handname[i])
-        fmt.Printf("%d\n\n",score)
-	GUI_update_score(score)
+	/* the reduced bet (below) is shown next time, when the hand is dealt */
+	view.Paytable(i)
 
         hands++
 
@@ -948,26 +1170,27 @@ func draw() {
 
                 if score < bet {
 		//
-			msg = fmt.Sprintf("You ran out of chips after playing %d hands", hands)
-			GUI_update_message(msg)
-			fmt.Printf("%s\n",msg)
-//			fmt.Printf("You ran out of chips after playing %d hands.\n", hands)
-//			if score_high > INITCHIPS { fmt.Printf("At one point, you had %d chips.\n", score_high) }
-			os.Exit(0)
+			msg = fmt.Sprintf(tr("You ran out of chips after playing %s hands"), number(hands))
+			printf("%s\n",msg)
+//			printf("You ran out of chips after playing %d hands.\n", hands)
+//			if score_high > INITCHIPS { printf("At one point, you had %d chips.\n", score_high) }
+			end_session(tr("You ran out of chips"))
+			return
                 } else {
 		//
 // TODO: use dialog (alert) for this:
-			msg = fmt.Sprintf("You are low on chips. Your bet has been reduced to %d",bet)
-			GUI_update_message(msg)
-			fmt.Printf("%s\n\n",msg)
-//			fmt.Printf("You are low on chips. Your bet has been reduced to %d\n\n", bet)
+			msg = fmt.Sprintf(tr("You are low on chips. Your bet has been reduced to %s"),number(bet))
+			view.Message(msg)
+			printf("%s\n\n",msg)
+//			printf("You are low on chips. Your bet has been reduced to %d\n\n", bet)
 // TODO: update bet buttons
                 }
         }
 
 	state = Deal
-	GUI_update_button()
-	GUI_update_message(msg_deal)
+	view.Button(state)
+	view.Message(tr(msg_deal))
+	check_limits()
 }
 
 // The following just starts (initializes) the game
@@ -0,0 +1,188 @@
+// Views of the Video Poker game
+
+// The engine doesn't change the web page (or the terminal) itself.
+// It tells a View what has happened, and the View shows it.
+// The web app's View is in main.go, the terminal's is in terminal.go,
+// and Recorder, below, writes down what the engine did, for tests.
+
+package videopoker
+
+import (
+	"fmt"
+	"strings"
+	)
+
+type View interface {
+	Message(msg string)		// the message line
+	Game(g int)			// the game was changed
+	HandName(name string)		// the name of the winning hand, or " "
+	Hand(hand [CARDS]Card)		// the cards in the hand
+	Hold(n int, held bool)		// card n (0 to 4) was held or un-held
+	FaceDown(n int)			// card n is being replaced, and is turned over by the next Hand()
+	Score(score int)		// the number of chips
+	Button(state int)		// the state changed, so the Deal/Draw button does something else
+	Paytable(win int)		// the pay table changed, or win (if not NOTHING) is the hand that won
+	Summary(lines []string)		// the summary at the end of a session, or nil to hide it
+	Announce(msg string)		// something for a screen reader to say
+	Sound(name string)		// a sound effect: "deal", "draw", "hold" or "unhold"
+	Win(handtype int)		// the hand was scored, so play a win sound if it won
+	Limit(msg string)		// play stopped at a limit until it's acknowledged, or "" when it goes on (see limits.go)
+}
+
+/* The view that is used until Start() is called */
+
+var view View = NoView{}
+
+/*
+	A View that doesn't show anything.
+	It can be put in a struct to get the methods it doesn't need.
+*/
+
+type NoView struct{}
+
+func (NoView) Message(msg string)	{}
+func (NoView) Game(g int)		{}
+func (NoView) HandName(name string)	{}
+func (NoView) Hand(hand [CARDS]Card)	{}
+func (NoView) Hold(n int, held bool)	{}
+func (NoView) FaceDown(n int)		{}
+func (NoView) Score(score int)		{}
+func (NoView) Button(state int)		{}
+func (NoView) Paytable(win int)		{}
+func (NoView) Summary(lines []string)	{}
+func (NoView) Announce(msg string)	{}
+func (NoView) Sound(name string)	{}
+func (NoView) Win(handtype int)		{}
+func (NoView) Limit(msg string)		{}
+
+/*
+	A View that writes down each thing the engine does, one string per call,
+	like "Score 990" or "Hold 2 true", so tests can check what happened.
+*/
+
+type Recorder struct {
+	Events []string
+}
+
+func (r *Recorder) record(format string, a ...interface{}) {
+//
+	r.Events = append(r.Events, fmt.Sprintf(format, a...))
+}
+
+/* Forget what has been recorded */
+
+func (r *Recorder) Reset() {
+//
+	r.Events = nil
+}
+
+/* The events that start with prefix, like "Score" */
+
+func (r *Recorder) Find(prefix string) []string {
+//
+	var found []string
+
+	for _, e := range r.Events {
+	//
+		if strings.HasPrefix(e, prefix) { found = append(found, e) }
+	}
+	return found
+}
+
+func (r *Recorder) Message(msg string)		{ r.record("Message %s", msg) }
+func (r *Recorder) Game(g int)			{ r.record("Game %d", g) }
+func (r *Recorder) HandName(name string)	{ r.record("HandName %s", name) }
+func (r *Recorder) Hold(n int, held bool)	{ r.record("Hold %d %t", n, held) }
+func (r *Recorder) FaceDown(n int)		{ r.record("FaceDown %d", n) }
+func (r *Recorder) Score(score int)		{ r.record("Score %d", score) }
+func (r *Recorder) Button(state int)		{ r.record("Button %d", state) }
+func (r *Recorder) Paytable(win int)		{ r.record("Paytable %d", win) }
+func (r *Recorder) Summary(lines []string)	{ r.record("Summary %s", strings.Join(lines, " / ")) }
+func (r *Recorder) Announce(msg string)		{ r.record("Announce %s", msg) }
+func (r *Recorder) Sound(name string)		{ r.record("Sound %s", name) }
+func (r *Recorder) Win(handtype int)		{ r.record("Win %d", handtype) }
+func (r *Recorder) Limit(msg string)		{ r.record("Limit %s", msg) }
+
+func (r *Recorder) Hand(hand [CARDS]Card) {
+//
+	var cards []string
+
+	for _, c := range hand { cards = append(cards, c.String()) }
+	r.record("Hand %s", strings.Join(cards, " "))
+}
+
+/*
+	A View that keeps the calls, so they can be sent somewhere else, like
+	from a server to the browser, and shown there with Play().
+*/
+
+type Call struct {
+	Method string		`json:"method"`
+	N int			`json:"n,omitempty"`		// the card number, game, score, state or hand type
+	Held bool		`json:"held,omitempty"`
+	Text string		`json:"text,omitempty"`
+	Lines []string		`json:"lines,omitempty"`
+	Hand []string		`json:"hand,omitempty"`	// like "10h", or "" for no card
+}
+
+type Script struct {
+	Calls []Call
+}
+
+func (s *Script) add(c Call) {
+//
+	s.Calls = append(s.Calls, c)
+}
+
+func (s *Script) Message(msg string)		{ s.add(Call{ Method: "Message", Text: msg }) }
+func (s *Script) Game(g int)			{ s.add(Call{ Method: "Game", N: g }) }
+func (s *Script) HandName(name string)		{ s.add(Call{ Method: "HandName", Text: name }) }
+func (s *Script) Hold(n int, held bool)		{ s.add(Call{ Method: "Hold", N: n, Held: held }) }
+func (s *Script) FaceDown(n int)		{ s.add(Call{ Method: "FaceDown", N: n }) }
+func (s *Script) Score(score int)		{ s.add(Call{ Method: "Score", N: score }) }
+func (s *Script) Button(state int)		{ s.add(Call{ Method: "Button", N: state }) }
+func (s *Script) Paytable(win int)		{ s.add(Call{ Method: "Paytable", N: win }) }
+func (s *Script) Summary(lines []string)	{ s.add(Call{ Method: "Summary", Lines: lines }) }
+func (s *Script) Announce(msg string)		{ s.add(Call{ Method: "Announce", Text: msg }) }
+func (s *Script) Sound(name string)		{ s.add(Call{ Method: "Sound", Text: name }) }
+func (s *Script) Win(handtype int)		{ s.add(Call{ Method: "Win", N: handtype }) }
+func (s *Script) Limit(msg string)		{ s.add(Call{ Method: "Limit", Text: msg }) }
+
+func (s *Script) Hand(hand [CARDS]Card) {
+//
+	c := Call{ Method: "Hand" }
+	for _, h := range hand {
+	//
+		if h.Blank() { c.Hand = append(c.Hand, "") } else { c.Hand = append(c.Hand, h.String()) }
+	}
+	s.add(c)
+}
+
+/* Make the calls again, on v */
+
+func (s *Script) Play(v View) {
+//
+	for _, c := range s.Calls {
+	//
+		switch c.Method {
+		//
+			case "Message":	v.Message(c.Text)
+			case "Game":		v.Game(c.N)
+			case "HandName":	v.HandName(c.Text)
+			case "Hold":		v.Hold(c.N, c.Held)
+			case "FaceDown":	v.FaceDown(c.N)
+			case "Score":		v.Score(c.N)
+			case "Button":		v.Button(c.N)
+			case "Paytable":	v.Paytable(c.N)
+			case "Summary":		v.Summary(c.Lines)
+			case "Announce":	v.Announce(c.Text)
+			case "Sound":		v.Sound(c.Text)
+			case "Win":		v.Win(c.N)
+			case "Limit":		v.Limit(c.Text)
+			case "Hand":
+				var h [CARDS]Card
+				for i := 0; i < CARDS && i < len(c.Hand); i++ { h[i], _ = ParseCard(c.Hand[i]) }
+				v.Hand(h)
+		}
+	}
+}
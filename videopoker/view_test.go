@@ -0,0 +1,33 @@
+// Tests for the Views
+
+package videopoker
+
+import "testing"
+
+/* NoView and Recorder are both Views */
+
+var _ View = NoView{}
+var _ View = &Recorder{}
+
+func TestRecorder(t *testing.T) {
+//
+	r := start_test(t)
+
+	Bet(2)
+	DealOrDraw()
+	if got := r.Find("Score"); len(got) != 1 || got[0] != "Score 980" { t.Errorf("scores: %v", got) }
+	if len(r.Find("Hand ")) == 0 || len(r.Find("Button 1")) != 1 { t.Errorf("deal: %v", r.Events) }
+
+	r.Reset()
+	toggle_hold(2)
+	if len(r.Events) < 2 || r.Events[0] != "Hold 2 true" || r.Events[1] != "Sound hold" {
+		t.Errorf("hold: %v", r.Events)
+	}
+	r.Reset()
+	if len(r.Events) != 0 || r.Find("") != nil { t.Errorf("after Reset: %v", r.Events) }
+
+	/* nothing is shown with NoView */
+	view = NoView{}
+	DealOrDraw()
+	if len(r.Events) != 0 { t.Errorf("recorded with NoView: %v", r.Events) }
+}
@@ -1,19 +1,156 @@
+//go:build !js
+
 // A basic HTTP server.
-// By default, it serves the current working directory on port 8080.
+// It serves the game's files on port 8080, with the right types and caching
+// (see staticfiles.go). The files are built into the program, so it's all that's
+// needed to run the game; -dir serves them from a directory instead, for development.
+// It also plays the game for the web page's remote mode (see apiserver.go),
+// keeps the leaderboard (see leaderboard.go), and logs and counts the requests
+// (see observe.go). It stops when it gets SIGTERM or ^C, after finishing
+// the requests it's working on.
+//
+//	GET /api/version	the version of the program, and how it was built
 package main
 
 import (
+	"context"
+	"embed"
+	"encoding/json"
 	"flag"
 	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"runtime/debug"
+	"syscall"
+	"time"
+
+	vp "github.com/Yaoir/VideoPoker-Go-WebAssembly/videopoker"
 )
 
+// The files for the web page. main.wasm has to be built first (see the Makefile),
+// and main.wasm.gz, main.wasm.br and main.wasm.sum are included if they're there.
+
+//go:embed index.html favicon.ico wasm_exec.js css img main.wasm*
+var game_files embed.FS
+
+// Set when building a release, with -ldflags "-X main.version=1.1"
+
+var version string = "1.0"
+
 var listen = flag.String("listen", ":8080", "listen address")
-var dir    = flag.String("dir", ".", "directory to serve")
+var dir    = flag.String("dir", "", "directory to serve, instead of the files built into the program")
+var board  = flag.String("leaderboard", "leaderboard.jsonl", "file for the leaderboard, or \"\" for none")
+var show_version = flag.Bool("version", false, "print the version and exit")
+var log_format = flag.String("log", "text", "format of the log: text or json")
+var key = flag.String("tournament-key", "", "a key needed for creating tournaments (see tournament.go)")
+var dev = flag.Bool("dev", false, "build main.wasm again when a Go file changes, and reload the page (see devmode.go)")
+
+// How long to wait for requests to finish when stopping
+
+const shutdown_timeout = 10 * time.Second
+
+type build_info struct {
+	Version string	`json:"version"`
+	Go string	`json:"go"`
+	Revision string	`json:"revision,omitempty"`	// the git commit it was built from
+	Time string	`json:"time,omitempty"`		// when that commit was made
+	Modified bool	`json:"modified,omitempty"`	// if there were changes that weren't committed
+	Files string	`json:"files"`			// "embedded", or the directory being served
+}
+
+func get_build_info() build_info {
+	info := build_info{ Version: version, Files: "embedded" }
+	if *dir != "" { info.Files = *dir }
+
+	b, ok := debug.ReadBuildInfo()
+	if !ok { return info }
+	info.Go = b.GoVersion
+	for _, s := range b.Settings {
+		switch s.Key {
+			case "vcs.revision": info.Revision = s.Value
+			case "vcs.time":     info.Time = s.Value
+			case "vcs.modified": info.Modified = s.Value == "true"
+		}
+	}
+	return info
+}
+
+// GET /api/version
+
+func api_version(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-cache")
+	json.NewEncoder(w).Encode(get_build_info())
+}
 
 func main() {
 	flag.Parse()
-	fmt.Printf("Web server running. Listening on %q", *listen)
-	err := http.ListenAndServe(*listen, http.FileServer(http.Dir(*dir)))
-	fmt.Printf("%v\n",err)
+	if *show_version {
+		info := get_build_info()
+		fmt.Printf("Video Poker web server %s (%s", info.Version, info.Go)
+		if info.Revision != "" { fmt.Printf(", %.12s", info.Revision) }
+		if info.Modified { fmt.Printf(", modified") }
+		fmt.Printf(")\n")
+		return
+	}
+	switch *log_format {
+		case "text": slog.SetDefault(slog.New(slog.NewTextHandler(os.Stdout, nil)))
+		case "json": slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+		default:
+			fmt.Fprintf(os.Stderr, "-log must be text or json\n")
+			os.Exit(2)
+	}
+	vp.Console = io.Discard	// the engine's text mode output is for one player
+	tournament_key = *key
+
+	if *board != "" {
+		var err error
+		if scores, err = open_leaderboard(*board); err != nil {
+			slog.Error("can't open the leaderboard", "error", err)
+			os.Exit(1)
+		}
+	}
+
+	if *dev && *dir == "" { *dir = "." }	// the files that are being worked on
+	var files fs.FS = game_files
+	if *dir != "" { files = os.DirFS(*dir) }
+	static := new_static_files(files)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", static)
+	mux.HandleFunc("GET /api/version", api_version)
+	register_api(mux)
+	register_observe(mux)
+
+	server := &http.Server{ Addr: *listen, Handler: observe(mux) }
+	server.RegisterOnShutdown(close_live)	// the spectators' WebSockets (see spectate.go)
+	if *dev { start_dev(*dir, static, mux, server) }
+	stopped := make(chan bool)
+
+	/* on SIGTERM or ^C, finish the requests that have started, then save the leaderboard */
+	signalled, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, os.Interrupt)
+	go func() {
+		<-signalled.Done()
+		stop()	// another ^C stops it right away
+		slog.Info("web server stopping")
+		ctx, cancel := context.WithTimeout(context.Background(), shutdown_timeout)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil { slog.Error("requests didn't finish", "error", err) }
+		if scores != nil {
+			if err := scores.close(); err != nil { slog.Error("can't save the leaderboard", "error", err) }
+		}
+		close(stopped)
+	}()
+
+	slog.Info("web server running", "listen", *listen, "version", version, "files", get_build_info().Files)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		slog.Error("web server failed", "error", err)
+		os.Exit(1)
+	}
+	<-stopped
+	slog.Info("web server stopped")
 }
@@ -0,0 +1,175 @@
+//go:build !js
+
+// WebSockets, for watching a game as it's played (see spectate.go)
+//
+// Just enough of RFC 6455 for short JSON messages: text messages of up to
+// max_ws_message bytes, in one frame or more, and pings and closes.
+// Messages from the browser are masked, and messages to it aren't.
+
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ws_guid = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+const max_ws_message = 4096
+const ws_write_timeout = 10 * time.Second
+
+const (
+	ws_continuation = 0x0
+	ws_text = 0x1
+	ws_binary = 0x2
+	ws_close = 0x8
+	ws_ping = 0x9
+	ws_pong = 0xa
+)
+
+type ws_conn struct {
+	conn net.Conn
+	in *bufio.Reader
+	write_lock sync.Mutex
+	closed bool
+}
+
+var ws_closed = errors.New("the WebSocket was closed")
+
+func header_has(h http.Header, name, value string) bool {
+	for _, v := range h.Values(name) {
+		for _, s := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(s), value) { return true }
+		}
+	}
+	return false
+}
+
+// Turn an HTTP request into a WebSocket. Pages from other sites can't open one.
+
+func ws_upgrade(w http.ResponseWriter, r *http.Request) (*ws_conn, error) {
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if !header_has(r.Header, "Connection", "upgrade") || !header_has(r.Header, "Upgrade", "websocket") ||
+		r.Header.Get("Sec-WebSocket-Version") != "13" || key == "" {
+		w.Header().Set("Sec-WebSocket-Version", "13")
+		http.Error(w, "this needs a WebSocket", http.StatusUpgradeRequired)
+		return nil, errors.New("not a WebSocket request")
+	}
+	if origin := r.Header.Get("Origin"); origin != "" {
+		if u, err := url.Parse(origin); err != nil || !strings.EqualFold(u.Host, r.Host) {
+			http.Error(w, "this page can't be watched from another site", http.StatusForbidden)
+			return nil, errors.New("WebSocket from another site")
+		}
+	}
+
+	conn, buf, err := http.NewResponseController(w).Hijack()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, err
+	}
+	sum := sha1.Sum([]byte(key + ws_guid))
+	accept := base64.StdEncoding.EncodeToString(sum[:])
+	conn.SetDeadline(time.Time{})
+	_, err = buf.WriteString("HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\n" +
+		"Sec-WebSocket-Accept: " + accept + "\r\n\r\n")
+	if err == nil { err = buf.Flush() }
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &ws_conn{ conn: conn, in: buf.Reader }, nil
+}
+
+func (c *ws_conn) write_frame(opcode byte, data []byte) error {
+	c.write_lock.Lock()
+	defer c.write_lock.Unlock()
+	if c.closed { return ws_closed }
+
+	header := []byte{ 0x80 | opcode }	// the last frame of the message
+	switch n := len(data); {
+		case n < 126: header = append(header, byte(n))
+		case n < 1<<16: header = append(header, 126, byte(n >> 8), byte(n))
+		default:
+			header = append(header, 127)
+			header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+	c.conn.SetWriteDeadline(time.Now().Add(ws_write_timeout))
+	_, err := c.conn.Write(append(header, data...))
+	return err
+}
+
+// Send a text message
+
+func (c *ws_conn) send(msg []byte) error {
+	return c.write_frame(ws_text, msg)
+}
+
+// Wait for the next text message. Pings are answered while waiting.
+
+func (c *ws_conn) receive() ([]byte, error) {
+	var msg []byte
+	for {
+		var h [2]byte
+		if _, err := io.ReadFull(c.in, h[:]); err != nil { return nil, err }
+		final, opcode := h[0] & 0x80 != 0, h[0] & 0x0f
+		masked, n := h[1] & 0x80 != 0, uint64(h[1] & 0x7f)
+		switch n {
+			case 126:
+				var b [2]byte
+				if _, err := io.ReadFull(c.in, b[:]); err != nil { return nil, err }
+				n = uint64(binary.BigEndian.Uint16(b[:]))
+			case 127:
+				var b [8]byte
+				if _, err := io.ReadFull(c.in, b[:]); err != nil { return nil, err }
+				n = binary.BigEndian.Uint64(b[:])
+		}
+		if !masked { return nil, c.fail("messages from the browser have to be masked") }
+		if n > max_ws_message || uint64(len(msg)) + n > max_ws_message { return nil, c.fail("the message is too long") }
+		var mask [4]byte
+		if _, err := io.ReadFull(c.in, mask[:]); err != nil { return nil, err }
+		data := make([]byte, n)
+		if _, err := io.ReadFull(c.in, data); err != nil { return nil, err }
+		for i := range data { data[i] ^= mask[i % 4] }
+
+		switch opcode {
+			case ws_ping:
+				c.write_frame(ws_pong, data)
+			case ws_pong:
+			case ws_close:
+				c.write_frame(ws_close, nil)
+				c.close()
+				return nil, ws_closed
+			case ws_text, ws_binary, ws_continuation:
+				msg = append(msg, data...)
+				if final { return msg, nil }
+			default:
+				return nil, c.fail("unknown opcode")
+		}
+	}
+}
+
+// Close the connection, after telling the other end why
+
+func (c *ws_conn) fail(reason string) error {
+	c.write_frame(ws_close, append([]byte{ 0x03, 0xea }, reason...))	// 1002, a protocol error
+	c.close()
+	return errors.New(reason)
+}
+
+func (c *ws_conn) close() {
+	c.write_lock.Lock()
+	defer c.write_lock.Unlock()
+	if !c.closed {
+		c.closed = true
+		c.conn.Close()
+	}
+}